@@ -0,0 +1,30 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/khayrultw/go-judge/config"
+	"github.com/khayrultw/go-judge/database"
+)
+
+// Run executes one of the command line modes of the server binary
+func Run(command string, args []string) error {
+	switch command {
+	case "import":
+		return Import(args)
+	case "export":
+		return Export(args)
+	case "worker":
+		return Worker(args)
+	case "judge":
+		return Judge(args)
+	}
+	return fmt.Errorf("unknown command %q", command)
+}
+
+func connect() error {
+	if err := config.LoadConfig(); err != nil {
+		return err
+	}
+	return database.InitDb()
+}
@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/khayrultw/go-judge/database"
+	"github.com/khayrultw/go-judge/models"
+	"github.com/khayrultw/go-judge/problempkg"
+)
+
+// Export writes a problem as a problem package:
+//
+//	go-judge export -problem 12 problem.zip
+func Export(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	problemId := flags.Uint("problem", 0, "id of the problem to export")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 || *problemId == 0 {
+		return fmt.Errorf("usage: go-judge export -problem <id> <package.zip>")
+	}
+
+	if err := connect(); err != nil {
+		return err
+	}
+	var problem models.Problem
+	if err := database.Db.Preload("Solutions").Preload("Generators").Preload("Tags").Preload("Statements").First(&problem, *problemId).Error; err != nil {
+		return fmt.Errorf("problem %d not found", *problemId)
+	}
+
+	file, err := os.Create(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if err := problempkg.Write(file, problem); err != nil {
+		return err
+	}
+
+	fmt.Printf("Exported %q to %s\n", problem.Title, flags.Arg(0))
+	return nil
+}
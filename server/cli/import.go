@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/khayrultw/go-judge/database"
+	"github.com/khayrultw/go-judge/models"
+	"github.com/khayrultw/go-judge/problempkg"
+)
+
+// Import adds a Polygon or Kattis problem package to the archive, and to a
+// contest if one is given:
+//
+//	go-judge import -contest 3 -number 0 package.zip
+func Import(args []string) error {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	contestId := flags.Uint("contest", 0, "id of the contest the problem is added to")
+	problemNumber := flags.Uint("number", 0, "number of the problem inside the contest")
+	label := flags.String("label", "", "label of the problem inside the contest, A for number 0 by default")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: go-judge import [-contest <id> [-number <n>] [-label <label>]] <package.zip>")
+	}
+	if *problemNumber > 255 {
+		return fmt.Errorf("problem number must be less than 256")
+	}
+
+	pkg, err := problempkg.ReadFile(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	for _, warning := range pkg.Warnings {
+		fmt.Println("warning:", warning)
+	}
+
+	if err := connect(); err != nil {
+		return err
+	}
+	var link *models.ContestProblem
+	if *contestId != 0 {
+		link = &models.ContestProblem{ContestId: *contestId, Position: uint8(*problemNumber), Label: *label}
+	}
+	problem, err := problempkg.Save(database.Db, pkg, link, 0)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Imported %q as problem %d with %d tests and %d solutions\n", problem.Title, problem.Id, len(pkg.Tests), len(pkg.Solutions))
+	return nil
+}
@@ -0,0 +1,146 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/khayrultw/go-judge/judge"
+	"github.com/khayrultw/go-judge/models"
+	"github.com/khayrultw/go-judge/problempkg"
+)
+
+// Judge judges a solution against a problem package, without the server or
+// the database, and prints the verdict of every test:
+//
+//	go-judge judge -lang cpp -problem ./aplusb solution.cpp
+//
+// The problem is a directory or zip in the export format, or any other
+// package Import reads. The solution can be a directory of files, built
+// like a multi-file submission.
+func Judge(args []string) error {
+	flags := flag.NewFlagSet("judge", flag.ExitOnError)
+	lang := flags.String("lang", "", "language of the solution: "+strings.Join(judge.Languages, ", "))
+	problemPath := flags.String("problem", "", "problem package directory or zip")
+	timeLimit := flags.Uint("time", 0, "time limit in milliseconds instead of the problem's")
+	memoryLimit := flags.Uint("memory", 0, "memory limit in megabytes instead of the problem's")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 || *lang == "" || *problemPath == "" {
+		return fmt.Errorf("usage: go-judge judge -lang <language> -problem <dir|package.zip> [-time <ms>] [-memory <MB>] <solution>")
+	}
+
+	var pkg *problempkg.Package
+	var err error
+	if strings.HasSuffix(*problemPath, ".zip") {
+		pkg, err = problempkg.ReadFile(*problemPath)
+	} else {
+		pkg, err = problempkg.ReadDir(*problemPath)
+	}
+	if err != nil {
+		return err
+	}
+	for _, warning := range pkg.Warnings {
+		fmt.Println("warning:", warning)
+	}
+	if pkg.Type == models.ProblemTypeOutputOnly {
+		return fmt.Errorf("%q is output-only, it has no code to judge", pkg.Title)
+	}
+
+	source, files, err := readSolution(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	opts := judge.Options{
+		TimeLimit:       pkg.TimeLimit,
+		MemoryLimit:     pkg.MemoryLimit,
+		CheckerProtocol: pkg.CheckerProtocol,
+		Harnesses:       pkg.Harnesses,
+		InputFile:       pkg.InputFile,
+		OutputFile:      pkg.OutputFile,
+	}
+	if *timeLimit > 0 {
+		opts.TimeLimit = *timeLimit
+	}
+	if *memoryLimit > 0 {
+		opts.MemoryLimit = *memoryLimit
+	}
+	if pkg.Checker != nil {
+		// the checker is compiled next to its headers, like on the server
+		dir, err := os.MkdirTemp("", "checker-")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(dir)
+		for name, content := range pkg.Resources {
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+				return err
+			}
+		}
+		opts.CheckerPath = filepath.Join(dir, pkg.Checker.Name)
+		opts.CheckerLanguage = pkg.Checker.Language
+		if err := os.WriteFile(opts.CheckerPath, []byte(pkg.Checker.Source), 0644); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("Judging %s on %q, %d tests\n", flags.Arg(0), pkg.Title, len(pkg.Tests))
+	reports, err := judge.JudgeEach(source, files, *lang, pkg.Tests, opts)
+	if err != nil {
+		fmt.Println(err)
+		return fmt.Errorf("%s: compilation failed", judge.VerdictCompileError)
+	}
+
+	fmt.Printf("%-6s %-8s %8s %10s\n", "test", "verdict", "time", "memory")
+	var failed *judge.TestReport
+	for i, report := range reports {
+		fmt.Printf("%-6d %-8s %5d ms %7d KB\n", report.Test, report.Verdict, report.Time, report.Memory)
+		if report.Verdict != judge.VerdictAccepted {
+			if message := strings.TrimSpace(report.Message); message != "" {
+				fmt.Println("       " + strings.ReplaceAll(message, "\n", "\n       "))
+			}
+			if failed == nil {
+				failed = &reports[i]
+			}
+		}
+	}
+	if failed != nil {
+		return fmt.Errorf("%s on test %d", failed.Verdict, failed.Test)
+	}
+	fmt.Println(judge.VerdictAccepted)
+	return nil
+}
+
+// readSolution reads a solution file, or the files of a solution directory
+func readSolution(name string) (string, []models.SubmissionFile, error) {
+	info, err := os.Stat(name)
+	if err != nil {
+		return "", nil, err
+	}
+	if !info.IsDir() {
+		source, err := os.ReadFile(name)
+		return string(source), nil, err
+	}
+
+	var files []models.SubmissionFile
+	err = filepath.WalkDir(name, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(name, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, models.SubmissionFile{Name: filepath.ToSlash(rel), Content: string(content)})
+		return nil
+	})
+	return "", files, err
+}
@@ -0,0 +1,343 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/khayrultw/go-judge/judge"
+	"github.com/khayrultw/go-judge/models"
+)
+
+// retryDelay is how long a worker waits after the server failed it
+const retryDelay = 5 * time.Second
+
+// errUnregistered means the server forgot the worker, after a restart
+var errUnregistered = errors.New("the worker is not registered")
+
+// Worker judges submissions of a server on another machine, with the judge
+// scripts of the working directory:
+//
+//	go-judge worker -server http://judge.example.com:8080 -languages cpp,py -jobs 2
+func Worker(args []string) error {
+	flags := flag.NewFlagSet("worker", flag.ExitOnError)
+	server := flags.String("server", "", "URL of the server")
+	token := flags.String("token", os.Getenv("JUDGE_WORKER_TOKEN"), "JUDGE_WORKER_TOKEN of the server")
+	hostname, _ := os.Hostname()
+	name := flags.String("name", hostname, "name of the worker shown to admins")
+	languages := flags.String("languages", strings.Join(judge.Languages, ","), "comma separated languages this machine can judge")
+	jobs := flags.Int("jobs", 1, "how many submissions are judged at once")
+	cacheDir, _ := os.UserCacheDir()
+	cache := flags.String("cache", filepath.Join(cacheDir, "go-judge"), "directory the tests are cached in")
+	flags.Parse(args)
+
+	if *server == "" || *token == "" || *jobs < 1 {
+		return fmt.Errorf("usage: go-judge worker -server <url> [-token <secret>] [-name <name>] [-languages cpp,py] [-jobs <n>] [-cache <dir>]")
+	}
+	for _, dir := range []string{"blobs", "jobs"} {
+		if err := os.MkdirAll(filepath.Join(*cache, dir), 0755); err != nil {
+			return err
+		}
+	}
+
+	w := &worker{
+		server:    strings.TrimSuffix(*server, "/"),
+		token:     *token,
+		name:      *name,
+		languages: strings.Split(*languages, ","),
+		cache:     *cache,
+		client:    &http.Client{Timeout: 2 * time.Minute},
+	}
+	if err := w.register(""); err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < *jobs; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.loop()
+		}()
+	}
+	wg.Wait()
+	return nil
+}
+
+type worker struct {
+	server    string
+	token     string
+	name      string
+	languages []string
+	cache     string
+	client    *http.Client
+
+	mu sync.Mutex
+	id string
+}
+
+// register registers the worker, unless it registered again since it got
+// the id stale
+func (w *worker) register(stale string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.id != stale {
+		return nil
+	}
+	var registered judge.RemoteWorker
+	body := map[string]interface{}{"name": w.name, "languages": w.languages}
+	if _, err := w.call(http.MethodPost, "/workers", body, &registered); err != nil {
+		return fmt.Errorf("Failed to register: %v", err)
+	}
+	w.id = registered.Id
+	log.Printf("Registered as %s for %s", w.id, strings.Join(w.languages, ", "))
+	return nil
+}
+
+func (w *worker) workerId() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.id
+}
+
+func (w *worker) loop() {
+	for {
+		id := w.workerId()
+		var job judge.RemoteJob
+		status, err := w.call(http.MethodPost, "/lease", map[string]string{"worker_id": id}, &job)
+		if errors.Is(err, errUnregistered) {
+			err = w.register(id)
+		}
+		if err != nil {
+			log.Printf("Failed to lease a job: %v", err)
+			time.Sleep(retryDelay)
+			continue
+		}
+		if status == http.StatusNoContent {
+			continue
+		}
+
+		result, ok := w.judge(id, &job)
+		if !ok {
+			log.Printf("Submission %d was cancelled", job.SubmissionId)
+			continue
+		}
+		for attempt := 0; attempt < 3; attempt++ {
+			if _, err = w.call(http.MethodPost, "/result", result, nil); err == nil {
+				break
+			}
+			time.Sleep(retryDelay)
+		}
+		if err != nil {
+			log.Printf("Failed to report submission %d: %v", job.SubmissionId, err)
+		}
+	}
+}
+
+// judge judges a job like the server would. It reports false if the job
+// was cancelled meanwhile.
+func (w *worker) judge(id string, job *judge.RemoteJob) (judge.RemoteResult, bool) {
+	report := judge.RemoteResult{WorkerId: id, SubmissionId: job.SubmissionId}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go w.heartbeat(ctx, cancel, id, job.SubmissionId)
+
+	dir, err := w.fetch(job.Data)
+	if err != nil {
+		report.Status, report.Message = "ERROR", err.Error()
+		return report, true
+	}
+
+	opts := judge.Options{
+		TimeLimit:       job.TimeLimit,
+		MemoryLimit:     job.MemoryLimit,
+		CheckerLanguage: job.CheckerLanguage,
+		CheckerProtocol: job.CheckerProtocol,
+		Harnesses:       job.Harnesses,
+		InputFile:       job.InputFile,
+		OutputFile:      job.OutputFile,
+		Context:         ctx,
+	}
+	if job.Checker != "" {
+		opts.CheckerPath = filepath.Join(dir, job.Checker)
+	}
+	opts.Progress = func(stage string, test, tests int) {
+		progress := judge.RemoteProgress{WorkerId: id, SubmissionId: job.SubmissionId, Stage: stage, Test: test, Tests: tests}
+		var response struct {
+			Cancelled bool `json:"cancelled"`
+		}
+		if _, err := w.call(http.MethodPost, "/progress", progress, &response); err == nil && response.Cancelled {
+			cancel()
+		}
+	}
+
+	testCasePath := filepath.Join(dir, judge.RemoteTestFile)
+	var result models.Result
+	switch {
+	case job.OutputOnly:
+		result, report.Tests = judge.JudgeOutputs(job.Outputs, testCasePath, opts)
+	case len(job.Files) > 0:
+		result = judge.JudgeFiles(job.Files, testCasePath, job.Language, opts)
+	default:
+		result = judge.JudgeWithOptions(job.SourceCode, testCasePath, job.Language, opts)
+	}
+	if ctx.Err() != nil {
+		return report, false
+	}
+	report.Status, report.Message = result.Status, result.Message
+	return report, true
+}
+
+// heartbeat renews the lease of a job until ctx is done, also while it
+// compiles or runs a long test, and cancels the job once the server says it
+// is no longer ours
+func (w *worker) heartbeat(ctx context.Context, cancel context.CancelFunc, id string, submissionId uint) {
+	ticker := time.NewTicker(judge.HeartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		var response struct {
+			Cancelled bool `json:"cancelled"`
+		}
+		body := map[string]interface{}{"worker_id": id, "submission_id": submissionId}
+		_, err := w.call(http.MethodPost, "/heartbeat", body, &response)
+		switch {
+		case errors.Is(err, errUnregistered) || err == nil && response.Cancelled:
+			cancel()
+			return
+		case err != nil:
+			log.Printf("Failed to renew the lease of submission %d: %v", submissionId, err)
+		}
+	}
+}
+
+// fetch puts the files of a job in a directory of their own, downloading
+// the ones that are not cached. Jobs with the same files share the
+// directory, so their checker is compiled once.
+func (w *worker) fetch(files []judge.RemoteFile) (string, error) {
+	key := sha256.New()
+	for _, file := range files {
+		fmt.Fprintf(key, "%s:%s\n", file.Name, file.Hash)
+	}
+	dir := filepath.Join(w.cache, "jobs", hex.EncodeToString(key.Sum(nil)))
+	if _, err := os.Stat(dir); err == nil {
+		return dir, nil
+	}
+
+	tmp, err := os.MkdirTemp(filepath.Join(w.cache, "jobs"), "tmp-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmp)
+	for _, file := range files {
+		if file.Name != filepath.Base(file.Name) || strings.HasPrefix(file.Name, ".") {
+			return "", fmt.Errorf("Invalid file name %q", file.Name)
+		}
+		blob, err := w.blob(file.Hash)
+		if err != nil {
+			return "", err
+		}
+		if err := os.Link(blob, filepath.Join(tmp, file.Name)); err != nil {
+			return "", err
+		}
+	}
+	// another job with the same files may have won the race
+	if err := os.Rename(tmp, dir); err != nil {
+		if _, statErr := os.Stat(dir); statErr != nil {
+			return "", err
+		}
+	}
+	return dir, nil
+}
+
+// blob downloads a file by its hash into the cache, once
+func (w *worker) blob(hash string) (string, error) {
+	if _, err := hex.DecodeString(hash); err != nil || len(hash) != sha256.Size*2 {
+		return "", fmt.Errorf("Invalid file hash %q", hash)
+	}
+	path := filepath.Join(w.cache, "blobs", hash)
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+
+	req, err := http.NewRequest(http.MethodGet, w.server+"/api/judge/files/"+hash, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+w.token)
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Failed to download %s: %s", hash, resp.Status)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Join(w.cache, "blobs"), "tmp-")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	h := sha256.New()
+	_, err = io.Copy(io.MultiWriter(tmp, h), resp.Body)
+	tmp.Close()
+	if err != nil {
+		return "", err
+	}
+	if hex.EncodeToString(h.Sum(nil)) != hash {
+		return "", fmt.Errorf("Download of %s is corrupt", hash)
+	}
+	return path, os.Rename(tmp.Name(), path)
+}
+
+// call sends a JSON request to the worker API of the server and decodes the
+// response into out, if there is one
+func (w *worker) call(method, path string, body, out interface{}) (int, error) {
+	content, err := json.Marshal(body)
+	if err != nil {
+		return 0, err
+	}
+	req, err := http.NewRequest(method, w.server+"/api/judge"+path, bytes.NewReader(content))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Authorization", "Bearer "+w.token)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return resp.StatusCode, errUnregistered
+	case resp.StatusCode >= 300:
+		var failure struct {
+			Error string `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&failure)
+		return resp.StatusCode, fmt.Errorf("%s: %s", resp.Status, failure.Error)
+	case out != nil && resp.StatusCode != http.StatusNoContent:
+		return resp.StatusCode, json.NewDecoder(resp.Body).Decode(out)
+	}
+	return resp.StatusCode, nil
+}
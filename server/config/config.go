@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"runtime"
+	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
@@ -15,6 +18,15 @@ type Config struct {
 	DBPassword string
 	DBName     string
 	JWTSecret  string
+	// JudgeWorkers is how many submissions this process judges at once,
+	// one per CPU unless set. With 0 only remote workers judge.
+	JudgeWorkers int
+	// JudgeWorkerToken is the secret remote judge workers authenticate
+	// with. Remote workers are disabled without it.
+	JudgeWorkerToken string
+	// JudgeLanguages are the languages the self-test checks, all of them
+	// unless set
+	JudgeLanguages []string
 }
 
 var envConfig Config
@@ -33,8 +45,19 @@ func LoadConfig() error {
 		DBName:     os.Getenv("DB_NAME"),
 		JWTSecret:  os.Getenv("JWT_SECRET"),
 	}
+	envConfig.JudgeWorkers = runtime.NumCPU()
+	if workers := os.Getenv("JUDGE_WORKERS"); workers != "" {
+		envConfig.JudgeWorkers, err = strconv.Atoi(workers)
+		if err != nil || envConfig.JudgeWorkers < 0 {
+			log.Fatalf("JUDGE_WORKERS must be a number of workers, 0 or more, not %q", workers)
+		}
+	}
+	envConfig.JudgeWorkerToken = os.Getenv("JUDGE_WORKER_TOKEN")
+	if languages := os.Getenv("JUDGE_LANGUAGES"); languages != "" {
+		envConfig.JudgeLanguages = strings.Split(languages, ",")
+	}
 
-	fmt.Printf("Config Loaded: %+v\n", envConfig)
+	fmt.Printf("Config Loaded: %+v\n", envConfig.redacted())
 
 	if envConfig.DBHost == "" || envConfig.DBPort == "" || envConfig.DBUser == "" || envConfig.DBPassword == "" || envConfig.DBName == "" {
 		log.Fatal("Missing required environment variables. Please check your .env file.")
@@ -43,6 +66,16 @@ func LoadConfig() error {
 	return nil
 }
 
+// redacted is the config with the secrets hidden, for logging
+func (c Config) redacted() Config {
+	for _, secret := range []*string{&c.DBPassword, &c.JWTSecret, &c.JudgeWorkerToken} {
+		if *secret != "" {
+			*secret = "<redacted>"
+		}
+	}
+	return c
+}
+
 func GetConfig() Config {
 	return envConfig
 }
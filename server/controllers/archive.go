@@ -0,0 +1,175 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/khayrultw/go-judge/models"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// ListProblems searches the practice archive. Admins search every problem,
+// so that they can pick problems for their contests. The query parameters
+// are all optional:
+//
+//	tag             comma separated, problems must have all of them
+//	min_difficulty  lowest difficulty, inclusive
+//	max_difficulty  highest difficulty, inclusive
+//	status          solved or unsolved, by the current user
+//	q               text the title contains
+//	page, per_page  pagination, from page 1 with 20 problems per page
+func (pc *ProblemController) ListProblems(c *gin.Context) {
+	userId := c.GetUint("userId")
+	query := pc.Db.Model(&models.Problem{})
+	if c.GetString("role") != "admin" {
+		query = query.Where("problems.public = ?", true)
+	}
+
+	if tags := parseTags(c.Query("tag")); len(tags) > 0 {
+		query = query.Where(`problems.id IN (SELECT problem_tags.problem_id FROM problem_tags
+			JOIN tags ON tags.id = problem_tags.tag_id WHERE tags.name IN ?
+			GROUP BY problem_tags.problem_id HAVING COUNT(*) = ?)`, tags, len(tags))
+	}
+
+	for _, bound := range []struct{ param, condition string }{
+		{"min_difficulty", "problems.difficulty >= ?"},
+		{"max_difficulty", "problems.difficulty <= ?"},
+	} {
+		value := c.Query(bound.param)
+		if value == "" {
+			continue
+		}
+		difficulty, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + bound.param})
+			return
+		}
+		query = query.Where(bound.condition, difficulty)
+	}
+
+	solved := `EXISTS (SELECT 1 FROM submissions WHERE submissions.problem_id = problems.id
+		AND submissions.user_id = ? AND submissions.status = 'PASS')`
+	switch c.Query("status") {
+	case "":
+	case "solved":
+		query = query.Where(solved, userId)
+	case "unsolved":
+		query = query.Where("NOT "+solved, userId)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be solved or unsolved"})
+		return
+	}
+
+	if text := strings.TrimSpace(c.Query("q")); text != "" {
+		query = query.Where("problems.title ILIKE ?", "%"+escapeLike(text)+"%")
+	}
+
+	page, perPage, err := parsePage(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// the same conditions count and list the problems
+	query = query.Session(&gorm.Session{})
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve problems"})
+		return
+	}
+
+	problems := []models.Problem{}
+	err = query.Select("problems.*, "+solved+" AS solved", userId).
+		Preload("Tags").
+		Order("problems.id ASC").
+		Offset((page - 1) * perPage).
+		Limit(perPage).
+		Find(&problems).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve problems"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"problems": problems, "total": total, "page": page, "per_page": perPage})
+}
+
+// ListTags returns every tag with the number of problems that have it
+func (pc *ProblemController) ListTags(c *gin.Context) {
+	type TagCount struct {
+		models.Tag
+		Problems int `json:"problems"`
+	}
+	tags := []TagCount{}
+	err := pc.Db.Model(&models.Tag{}).
+		Select("tags.*, COUNT(problem_tags.problem_id) AS problems").
+		Joins("LEFT JOIN problem_tags ON problem_tags.tag_id = tags.id").
+		Group("tags.id").
+		Order("tags.name ASC").
+		Scan(&tags).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve tags"})
+		return
+	}
+	c.JSON(http.StatusOK, tags)
+}
+
+// parseTags splits a comma separated list of tags. Tags are lower case and
+// never repeated.
+func parseTags(raw string) []string {
+	tags := []string{}
+	seen := make(map[string]bool)
+	for _, tag := range strings.Split(raw, ",") {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+	return tags
+}
+
+// parseDifficulty reads the optional difficulty form field, 0 if missing
+func parseDifficulty(c *gin.Context) (uint, error) {
+	value := c.PostForm("difficulty")
+	if value == "" {
+		return 0, nil
+	}
+	difficulty, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid difficulty")
+	}
+	return uint(difficulty), nil
+}
+
+func parsePage(c *gin.Context) (int, int, error) {
+	page, perPage := 1, defaultPageSize
+	if value := c.Query("page"); value != "" {
+		p, err := strconv.Atoi(value)
+		if err != nil || p < 1 {
+			return 0, 0, fmt.Errorf("Invalid page")
+		}
+		page = p
+	}
+	if value := c.Query("per_page"); value != "" {
+		p, err := strconv.Atoi(value)
+		if err != nil || p < 1 || p > maxPageSize {
+			return 0, 0, fmt.Errorf("per_page must be between 1 and %d", maxPageSize)
+		}
+		perPage = p
+	}
+	return page, perPage, nil
+}
+
+// escapeLike makes the wildcards of LIKE patterns match themselves
+func escapeLike(text string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(text)
+}
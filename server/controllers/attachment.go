@@ -0,0 +1,184 @@
+package controllers
+
+import (
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/khayrultw/go-judge/models"
+	"github.com/khayrultw/go-judge/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	maxAttachmentSize     = 10 << 20 // per file
+	maxProblemAttachments = 50 << 20 // all files of a problem together
+)
+
+const textContentType = "text/plain; charset=utf-8"
+
+// attachmentTypes are the kinds of files statements can refer to, by
+// extension. The content of an upload must match its extension.
+var attachmentTypes = map[string]string{
+	".png":  "image/png",
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".gif":  "image/gif",
+	".webp": "image/webp",
+	".pdf":  "application/pdf",
+	".zip":  "application/zip",
+	".txt":  textContentType,
+	".in":   textContentType,
+	".out":  textContentType,
+	".ans":  textContentType,
+}
+
+var attachmentName = regexp.MustCompile(`^[A-Za-z0-9_-][A-Za-z0-9._-]*$`)
+
+// UploadAttachment stores a file for the statement of a problem, from the
+// multipart field "file". The optional "name" field renames it.
+func (pc *ProblemController) UploadAttachment(c *gin.Context) {
+	var problem models.Problem
+	if err := pc.Db.First(&problem, c.Param("problemId")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Problem not found"})
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File is required"})
+		return
+	}
+	name := c.PostForm("name")
+	if name == "" {
+		name = filepath.Base(fileHeader.Filename)
+	}
+	if !attachmentName.MatchString(name) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Names may only contain letters, digits, '.', '_' and '-'"})
+		return
+	}
+	if fileHeader.Size > maxAttachmentSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("Attachments must be at most %d MB", maxAttachmentSize>>20)})
+		return
+	}
+
+	contentType, err := checkContentType(fileHeader, name)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// a file with the same name is replaced, so it doesn't count
+	var used int64
+	err = pc.Db.Model(&models.Attachment{}).
+		Where("problem_id = ? AND name <> ?", problem.Id, name).
+		Select("COALESCE(SUM(size), 0)").
+		Scan(&used).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check attachments"})
+		return
+	}
+	if used+fileHeader.Size > maxProblemAttachments {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("Attachments of a problem must be at most %d MB together", maxProblemAttachments>>20)})
+		return
+	}
+
+	attachment := models.Attachment{ProblemId: problem.Id, Name: name}
+	if err := pc.Db.Where(&attachment).FirstOrInit(&attachment).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save attachment"})
+		return
+	}
+	attachment.ContentType = contentType
+	attachment.Size = fileHeader.Size
+	attachment.Path = filepath.Join(utils.AttachmentDir(problem.Id), name)
+
+	if err := c.SaveUploadedFile(fileHeader, attachment.Path); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to write attachment"})
+		return
+	}
+	if err := pc.Db.Save(&attachment).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save attachment"})
+		return
+	}
+
+	attachment.URL = utils.AttachmentURL(problem.Id, attachment.Name)
+	c.JSON(http.StatusOK, attachment)
+}
+
+// checkContentType sniffs the start of the file and returns its content
+// type if it is allowed and matches the extension of name
+func checkContentType(fileHeader *multipart.FileHeader, name string) (string, error) {
+	expected, ok := attachmentTypes[strings.ToLower(filepath.Ext(name))]
+	if !ok {
+		return "", fmt.Errorf("Unsupported file type %s", filepath.Ext(name))
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return "", fmt.Errorf("Failed to read file")
+	}
+	defer file.Close()
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", fmt.Errorf("Failed to read file")
+	}
+
+	if detected := http.DetectContentType(head[:n]); detected != expected {
+		return "", fmt.Errorf("The content of %s is %s, expected %s", name, detected, expected)
+	}
+	return expected, nil
+}
+
+func (pc *ProblemController) ListAttachments(c *gin.Context) {
+	attachments := []models.Attachment{}
+	if err := pc.Db.Where("problem_id = ?", c.Param("problemId")).Order("name ASC").Find(&attachments).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve attachments"})
+		return
+	}
+	for i := range attachments {
+		attachments[i].URL = utils.AttachmentURL(attachments[i].ProblemId, attachments[i].Name)
+	}
+	c.JSON(http.StatusOK, attachments)
+}
+
+// ServeAttachment sends an attachment at its stable URL
+func (pc *ProblemController) ServeAttachment(c *gin.Context) {
+	var attachment models.Attachment
+	if err := pc.Db.Where("problem_id = ? AND name = ?", c.Param("problemId"), c.Param("name")).First(&attachment).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Attachment not found"})
+		return
+	}
+	c.Header("Content-Type", attachment.ContentType)
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.File(attachment.Path)
+}
+
+func (pc *ProblemController) DeleteAttachment(c *gin.Context) {
+	var attachment models.Attachment
+	if err := pc.Db.Where("problem_id = ? AND name = ?", c.Param("problemId"), c.Param("name")).First(&attachment).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Attachment not found"})
+		return
+	}
+	if err := pc.Db.Delete(&attachment).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete attachment"})
+		return
+	}
+	if err := os.Remove(attachment.Path); err != nil && !os.IsNotExist(err) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete attachment file"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Attachment deleted"})
+}
+
+// deleteAttachments removes the attachments of a problem that is being
+// deleted. The files go once the transaction commits.
+func deleteAttachments(tx *gorm.DB, problemId uint) error {
+	return tx.Where("problem_id = ?", problemId).Delete(&models.Attachment{}).Error
+}
@@ -43,10 +43,14 @@ func (cc *ContestController) CreateContest(c *gin.Context) {
 func (cc *ContestController) GetContest(c *gin.Context) {
 	contestId := c.Param("contestId")
 	var contest models.Contest
-	if err := cc.Db.Preload("Problems").First(&contest, contestId).Error; err != nil {
+	if err := cc.Db.First(&contest, contestId).Error; err != nil {
 		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "Contest not found"})
 		return
 	}
+	if err := contestProblems(cc.Db, contest.Id).Find(&contest.Problems).Error; err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve problems"})
+		return
+	}
 
 	c.JSON(http.StatusOK, contest)
 }
@@ -104,7 +108,7 @@ func (cc *ContestController) GetContestProblems(c *gin.Context) {
 		return
 	}
 	var problems []models.Problem
-	if err := cc.Db.Where("contest_id = ?", contestId).Find(&problems).Error; err != nil {
+	if err := contestProblems(cc.Db, contestId).Find(&problems).Error; err != nil {
 		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve problems"})
 		return
 	}
@@ -117,54 +121,82 @@ func stringToUint(s string) (uint, error) {
 	return uint(u64), err
 }
 
+// AddProblemToContest links a problem of the archive to the contest. The
+// label defaults to the letter of its position.
 func (cc *ContestController) AddProblemToContest(c *gin.Context) {
-	contestId := c.Param("contestId")
-	var problem models.Problem
-	if err := c.BindJSON(&problem); err != nil {
+	contestId, err := stringToUint(c.Param("contestId"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid contest ID"})
+		return
+	}
+	var link models.ContestProblem
+	if err := c.BindJSON(&link); err != nil || link.ProblemId == 0 {
 		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
 		return
 	}
-	contestIdUint, err := stringToUint(contestId)
-	if err != nil {
-		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid contest ID"})
+	link.ContestId = contestId
+
+	if err := cc.Db.First(&models.Contest{}, link.ContestId).Error; err != nil {
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "Contest not found"})
 		return
 	}
-	problem.ContestId = contestIdUint
-	if err := cc.Db.Create(&problem).Error; err != nil {
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := cc.Db.First(&models.Problem{}, link.ProblemId).Error; err != nil {
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "Problem not found"})
+		return
+	}
+	if link.Label == "" {
+		link.Label = models.DefaultLabel(link.Position)
+	}
+	if err := cc.Db.Create(&link).Error; err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to add problem to contest"})
 		return
 	}
 
-	c.JSON(http.StatusCreated, problem)
+	c.JSON(http.StatusCreated, link)
 }
 
+// DeleteProblemFromContest unlinks a problem from the contest. The problem
+// itself stays in the archive.
 func (cc *ContestController) DeleteProblemFromContest(c *gin.Context) {
-	contestId := c.Param("contest_id")
-	problemId := c.Param("problem_id")
+	contestId := c.Param("contestId")
+	problemId := c.Param("problemId")
 
-	if err := cc.Db.Where("contest_id = ? AND id = ?", contestId, problemId).Delete(&models.Problem{}).Error; err != nil {
+	result := cc.Db.Where("contest_id = ? AND problem_id = ?", contestId, problemId).Delete(&models.ContestProblem{})
+	if result.Error != nil {
 		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete problem from contest"})
 		return
 	}
+	if result.RowsAffected == 0 {
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "Problem is not part of this contest"})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Problem deleted from contest successfully"})
 }
 
+// UpdateProblemInContest changes the label or position of a problem in
+// the contest
 func (cc *ContestController) UpdateProblemInContest(c *gin.Context) {
-	contestId := c.Param("contest_id")
-	problemId := c.Param("problem_id")
-	var problem models.Problem
-	if err := c.BindJSON(&problem); err != nil {
+	contestId := c.Param("contestId")
+	problemId := c.Param("problemId")
+	var link models.ContestProblem
+	if err := cc.Db.Where("contest_id = ? AND problem_id = ?", contestId, problemId).First(&link).Error; err != nil {
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "Problem is not part of this contest"})
+		return
+	}
+	contestIdUint, problemIdUint := link.ContestId, link.ProblemId
+	if err := c.BindJSON(&link); err != nil {
 		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
 		return
 	}
+	link.ContestId, link.ProblemId = contestIdUint, problemIdUint
 
-	if err := cc.Db.Model(&problem).Where("contest_id = ? AND id = ?", contestId, problemId).Updates(problem).Error; err != nil {
+	if err := cc.Db.Model(&link).Select("Label", "Position").Updates(link).Error; err != nil {
 		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, problem)
+	c.JSON(http.StatusOK, link)
 }
 
 func (cc *ContestController) GetAllSubmissions(c *gin.Context) {
@@ -209,7 +241,7 @@ func (cc *ContestController) GetAllMySubmissionSSE(c *gin.Context) {
 	c.Writer.Header().Set("Connection", "keep-alive")
 
 	client := utils.GetBroadcaster().Subscribe("contest_submissions")
-	defer close(client)
+	defer utils.GetBroadcaster().Unsubscribe("contest_submissions", client)
 
 	for {
 		select {
@@ -247,7 +279,7 @@ func (cc *ContestController) GetMySubmissionsSSE(c *gin.Context) {
 	c.Writer.Header().Set("Connection", "keep-alive")
 
 	client := utils.GetBroadcaster().Subscribe("my_contest_submissions")
-	defer close(client)
+	defer utils.GetBroadcaster().Unsubscribe("my_contest_submissions", client)
 
 	for {
 		select {
@@ -280,7 +312,7 @@ func (sc *ContestController) getMySubmissions(userId uint, contestId string) ([]
 		Select("submissions.*, problems.title as problem_title").
 		Joins("LEFT JOIN problems ON problems.id = submissions.problem_id").
 		Where("submissions.user_id = ?", userId).
-		Where("problems.contest_id = ?", contestId)
+		Where("submissions.contest_id = ?", contestId)
 
 	var results []Result
 	err := query.Order("submissions.id desc").Scan(&results).Error
@@ -320,7 +352,7 @@ func (sc *ContestController) getAllSubmissions(contestId, role string) ([]models
 		Select("submissions.*, users.name as user_name, problems.title as problem_title").
 		Joins("LEFT JOIN users ON users.id = submissions.user_id").
 		Joins("LEFT JOIN problems ON problems.id = submissions.problem_id").
-		Where("problems.contest_id = ?", contestId)
+		Where("submissions.contest_id = ?", contestId)
 
 	err := query.Order("submissions.id desc").Limit(200).Scan(&results).Error
 	if err != nil {
@@ -350,9 +382,24 @@ func (sc *ContestController) getAllSubmissions(contestId, role string) ([]models
 	return response, nil
 }
 
+// contestProblems selects the problems of a contest in order, along with
+// the label and position they have in it
+func contestProblems(db *gorm.DB, contestId interface{}) *gorm.DB {
+	return withContestLinks(db).
+		Where("contest_problems.contest_id = ?", contestId).
+		Order("contest_problems.position ASC")
+}
+
+// withContestLinks joins problems with the contests they are used in
+func withContestLinks(db *gorm.DB) *gorm.DB {
+	return db.Model(&models.Problem{}).
+		Select("problems.*, contest_problems.contest_id, contest_problems.position AS problem_number, contest_problems.label").
+		Joins("JOIN contest_problems ON contest_problems.problem_id = problems.id")
+}
+
 func (cc *ContestController) getProblemsForContest(contestId string) ([]models.Problem, map[uint]int, []uint, error) {
 	var problems []models.Problem
-	if err := cc.Db.Where("contest_id = ?", contestId).Order("id ASC").Find(&problems).Error; err != nil {
+	if err := contestProblems(cc.Db, contestId).Find(&problems).Error; err != nil {
 		return nil, nil, nil, err
 	}
 	problemIds := make([]uint, len(problems))
@@ -397,7 +444,7 @@ func fillStandings(standings []models.UserStanding, submissions []models.Submiss
 		}
 		key := [2]uint{sub.UserId, sub.ProblemId}
 		pa := &standings[uid].Problems[pid]
-		if solvedMap[key] {
+		if solvedMap[key] || sub.Status == models.StatusDisqualified || sub.Status == models.StatusCancelled {
 			continue
 		}
 		pa.Count++
@@ -457,7 +504,7 @@ func (cc *ContestController) SSEStandings(c *gin.Context) {
 	c.Writer.Header().Set("Connection", "keep-alive")
 
 	client := utils.GetBroadcaster().Subscribe("standings")
-	defer close(client)
+	defer utils.GetBroadcaster().Unsubscribe("standings", client)
 
 	for {
 		select {
@@ -0,0 +1,30 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/khayrultw/go-judge/config"
+	"github.com/khayrultw/go-judge/judge"
+)
+
+// JudgeHealth reports the last self-test of the judge, which runs when the
+// server starts. It responds 503 if a language failed, for monitoring.
+func JudgeHealth(c *gin.Context) {
+	health, ok := judge.LastHealth()
+	if !ok {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "The self-test is still running"})
+		return
+	}
+	status := http.StatusOK
+	if !health.OK {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, health)
+}
+
+// RunSelfTest runs the self-test of the judge again and reports it
+func RunSelfTest(c *gin.Context) {
+	judge.SelfTest(config.GetConfig().JudgeLanguages)
+	JudgeHealth(c)
+}
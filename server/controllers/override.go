@@ -0,0 +1,126 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/khayrultw/go-judge/judge"
+	"github.com/khayrultw/go-judge/models"
+	"gorm.io/gorm"
+)
+
+// overrideStatuses are the verdicts an admin can give a submission by hand.
+// There is no score to override, the standings follow from the verdicts.
+var overrideStatuses = map[string]string{
+	judge.VerdictAccepted:    "PASS",
+	judge.VerdictWrongAnswer: "FAIL",
+	"DQ":                     models.StatusDisqualified,
+}
+
+// OverrideVerdict sets the verdict of a judged submission by hand. The
+// verdict of the judge is kept in automatic_status and the change is logged
+// with its reason.
+func (sc *SubmissionController) OverrideVerdict(c *gin.Context) {
+	var body struct {
+		Verdict string   `json:"verdict"`
+		Reason  string   `json:"reason"`
+		Score   *float64 `json:"score"`
+	}
+	if err := c.BindJSON(&body); err != nil {
+		return
+	}
+	if body.Score != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Submissions have no score to override, set the verdict instead"})
+		return
+	}
+	status, ok := overrideStatuses[body.Verdict]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Verdict must be AC, WA or DQ"})
+		return
+	}
+	sc.override(c, status, body.Reason)
+}
+
+// ClearOverride gives a submission the verdict of the judge back
+func (sc *SubmissionController) ClearOverride(c *gin.Context) {
+	var body struct {
+		Reason string `json:"reason"`
+	}
+	if err := c.BindJSON(&body); err != nil {
+		return
+	}
+	sc.override(c, "", body.Reason)
+}
+
+// override sets the status of the submission, or restores the automatic one
+// if status is empty
+func (sc *SubmissionController) override(c *gin.Context, status, reason string) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A reason is required"})
+		return
+	}
+
+	var submission models.Submission
+	if err := sc.Db.First(&submission, c.Param("submissionId")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Submission not found"})
+		return
+	}
+	if submission.Status == "pending" {
+		c.JSON(http.StatusConflict, gin.H{"error": "The submission is not judged yet"})
+		return
+	}
+
+	automatic := submission.AutomaticStatus
+	if status == "" {
+		if automatic == "" {
+			c.JSON(http.StatusConflict, gin.H{"error": "The verdict is not overridden"})
+			return
+		}
+		status, automatic = automatic, ""
+	} else if automatic == "" {
+		automatic = submission.Status
+	}
+
+	entry := models.VerdictOverride{
+		SubmissionId:   submission.Id,
+		ContestId:      submission.ContestId,
+		Status:         status,
+		PreviousStatus: submission.Status,
+		Reason:         reason,
+		UserId:         c.GetUint("userId"),
+	}
+	err := sc.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&entry).Error; err != nil {
+			return err
+		}
+		return tx.Model(&submission).Updates(map[string]interface{}{"status": status, "automatic_status": automatic}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to override verdict"})
+		return
+	}
+	submission.Status, submission.AutomaticStatus = status, automatic
+	judge.PublishSubmissions()
+
+	c.JSON(http.StatusOK, submission)
+}
+
+// ListOverrides returns the audit log of verdict overrides, newest first,
+// optionally of one contest or submission
+func (sc *SubmissionController) ListOverrides(c *gin.Context) {
+	query := sc.Db.Order("id DESC")
+	if contestId := c.Query("contest_id"); contestId != "" {
+		query = query.Where("contest_id = ?", contestId)
+	}
+	if submissionId := c.Query("submission_id"); submissionId != "" {
+		query = query.Where("submission_id = ?", submissionId)
+	}
+	overrides := []models.VerdictOverride{}
+	if err := query.Find(&overrides).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve overrides"})
+		return
+	}
+	c.JSON(http.StatusOK, overrides)
+}
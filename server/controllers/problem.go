@@ -1,18 +1,34 @@
 package controllers
 
 import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/khayrultw/go-judge/database"
+	"github.com/khayrultw/go-judge/judge"
 	"github.com/khayrultw/go-judge/models"
+	"github.com/khayrultw/go-judge/problempkg"
+	"github.com/khayrultw/go-judge/utils"
 	"gorm.io/gorm"
 )
 
+// ioFileName is what input and output files can be named, a file in the
+// working directory of the program
+var ioFileName = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
 type ProblemController struct {
 	Db *gorm.DB
 }
@@ -22,64 +38,449 @@ func NewProblemController() *ProblemController {
 	return &ProblemController{Db: db}
 }
 
-// CreateProblem handles file uploads and creates a new problem
+// CreateProblem handles file uploads and creates a new problem in the
+// archive, and in a contest if contest_id is given
 func (pc *ProblemController) CreateProblem(c *gin.Context) {
-	contestIdStr := c.PostForm("contest_id")
-	problemNumberStr := c.PostForm("problem_number")
+	link, err := pc.parseContestLink(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	contestId, err := strconv.ParseUint(contestIdStr, 10, 64)
+	public, err := parsePublic(c)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contest_id"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	problemNumber, err := strconv.ParseUint(problemNumberStr, 10, 8)
+
+	difficulty, err := parseDifficulty(c)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid problem_number"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	problemType, err := parseProblemType(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	inputFile, err := parseIOFile(c, "input_file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	outputFile, err := parseIOFile(c, "output_file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	language, err := parseLanguage(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	timeLimit, memoryLimit, err := parseLimits(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
 	testcaseText := c.PostForm("testcase")
-	if testcaseText == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Testcase text is required"})
+	script := c.PostForm("script")
+	if testcaseText == "" && script == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Testcase text or generator script is required"})
+		return
+	}
+
+	solutions, err := parseSolutions(c.PostForm("solutions"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	generators, err := parseGenerators(c.PostForm("generators"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	uploadDir := filepath.Join("store/test_cases", "contest_"+contestIdStr)
-	if err := os.MkdirAll(uploadDir, os.ModePerm); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create directory"})
+	harnesses, err := parseHarnesses(c.PostForm("harnesses"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	testcasePath := filepath.Join(uploadDir, fmt.Sprintf("c_%d_p_%d_testcase.txt", contestId, problemNumber))
-	if err := os.WriteFile(testcasePath, []byte(testcaseText), 0644); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to write testcase file"})
+	// a new problem has no files yet that generators could include
+	if script != "" {
+		opts := judge.Options{
+			TimeLimit:   timeLimit,
+			MemoryLimit: memoryLimit,
+			Harnesses:   harnesses,
+			InputFile:   inputFile,
+			OutputFile:  outputFile,
+		}
+		testcaseText, err = judge.GenerateTests(generators, script, solutions, opts, "")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	validatorSource := c.PostForm("validator")
+	validatorLang := c.PostForm("validator_language")
+	// a new problem has no files of its own to include yet
+	if !checkTests(c, validatorSource, validatorLang, testcaseText, "") {
 		return
 	}
 
+	samples, err := parseSamples(c.PostForm("samples"), testcaseText)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var sections models.StatementSections
+	parseSections(c, &sections)
 
 	problem := models.Problem{
-		ContestId:     uint(contestId),
-		Title:         c.PostForm("title"),
-		Statement:     c.PostForm("statement"),
-		TestCasePath:  testcasePath,
-		ProblemNumber: uint8(problemNumber),
+		Title:             c.PostForm("title"),
+		Statement:         sections.Markdown(nil),
+		StatementSections: sections,
+		SampleTests:       samples,
+		TestVersion:       1,
+		TimeLimit:         timeLimit,
+		MemoryLimit:       memoryLimit,
+		GeneratorScript:   script,
+		Public:            public,
+		Difficulty:        difficulty,
+		Source:            c.PostForm("source"),
+		DefaultLanguage:   language,
+		Solutions:         solutions,
+		Generators:        generators,
+		Harnesses:         harnesses,
+		Type:              problemType,
+		InputFile:         inputFile,
+		OutputFile:        outputFile,
 	}
 
-	if err := pc.Db.Create(&problem).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create problem"})
+	// files are stored by problem id, so they are written once the problem
+	// has one
+	err = pc.Db.Transaction(func(tx *gorm.DB) error {
+		tags, err := database.FindOrCreateTags(tx, parseTags(c.PostForm("tags")))
+		if err != nil {
+			return fmt.Errorf("Failed to save tags")
+		}
+		problem.Tags = tags
+		if err := tx.Create(&problem).Error; err != nil {
+			return fmt.Errorf("Failed to create problem")
+		}
+
+		problem.TestCasePath = utils.ProblemFilePath(problem.Id, utils.TestCaseFileName(1))
+		if err := utils.WriteProblemFile(problem.TestCasePath, testcaseText); err != nil {
+			return err
+		}
+		if validatorSource != "" {
+			problem.ValidatorPath = utils.ProblemFilePath(problem.Id, "validator."+validatorLang)
+			problem.ValidatorLanguage = validatorLang
+			if err := utils.WriteProblemFile(problem.ValidatorPath, validatorSource); err != nil {
+				return err
+			}
+		}
+		if err := database.SaveRevision(tx, &problem, c.GetUint("userId"), "Created"); err != nil {
+			return fmt.Errorf("Failed to create problem")
+		}
+
+		return addToContest(tx, &problem, link)
+	})
+	if err != nil {
+		// the id of the rolled back problem isn't used again
+		if problem.Id != 0 {
+			os.RemoveAll(utils.ProblemDir(problem.Id))
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, problem)
+	verifying := len(problem.Solutions) > 0 && pc.verify(c, problem.Id)
+
+	c.JSON(http.StatusOK, gin.H{"problem": problem, "verifying": verifying})
+}
+
+// verify queues the verification of the reference solutions of a problem,
+// whose verdicts GetVerification reports. A failure to queue it is logged,
+// the problem is saved by then.
+func (pc *ProblemController) verify(c *gin.Context, problemId uint) bool {
+	if err := judge.QueueVerification(pc.Db, problemId, c.GetUint("userId")); err != nil {
+		log.Printf("Problem %d: %v", problemId, err)
+		return false
+	}
+	return true
+}
+
+// parseContestLink reads the optional contest_id, problem_number and label
+// form fields. It returns nil when the problem only goes to the archive.
+func (pc *ProblemController) parseContestLink(c *gin.Context) (*models.ContestProblem, error) {
+	contestIdStr := c.PostForm("contest_id")
+	if contestIdStr == "" {
+		return nil, nil
+	}
+	contestId, err := strconv.ParseUint(contestIdStr, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid contest_id")
+	}
+	problemNumber, err := strconv.ParseUint(c.PostForm("problem_number"), 10, 8)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid problem_number")
+	}
+	if err := pc.Db.First(&models.Contest{}, contestId).Error; err != nil {
+		return nil, fmt.Errorf("Contest not found")
+	}
+
+	link := &models.ContestProblem{
+		ContestId: uint(contestId),
+		Position:  uint8(problemNumber),
+		Label:     c.PostForm("label"),
+	}
+	if link.Label == "" {
+		link.Label = models.DefaultLabel(link.Position)
+	}
+	return link, nil
+}
+
+// addToContest links a newly created problem to the contest it was created
+// for, if any
+func addToContest(tx *gorm.DB, problem *models.Problem, link *models.ContestProblem) error {
+	if link == nil {
+		return nil
+	}
+	link.ProblemId = problem.Id
+	if err := tx.Create(link).Error; err != nil {
+		return fmt.Errorf("Failed to add problem to contest")
+	}
+	problem.ContestId = link.ContestId
+	problem.ProblemNumber = link.Position
+	problem.Label = link.Label
+	return nil
+}
+
+// parsePublic reads the optional "public" form field, which puts the
+// problem in the practice archive
+func parsePublic(c *gin.Context) (bool, error) {
+	value := c.PostForm("public")
+	if value == "" {
+		return false, nil
+	}
+	public, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("Invalid public")
+	}
+	return public, nil
+}
+
+// parseProblemType reads the optional "type" form field, standard unless
+// the problem is output-only
+func parseProblemType(c *gin.Context) (string, error) {
+	switch problemType := c.PostForm("type"); problemType {
+	case "", models.ProblemTypeStandard:
+		return models.ProblemTypeStandard, nil
+	case models.ProblemTypeOutputOnly:
+		return problemType, nil
+	}
+	return "", fmt.Errorf("type must be %s or %s", models.ProblemTypeStandard, models.ProblemTypeOutputOnly)
+}
+
+// parseIOFile reads the optional form field naming the file a program
+// reads its input from or writes its output to, instead of stdin or stdout
+func parseIOFile(c *gin.Context, field string) (string, error) {
+	name := c.PostForm(field)
+	if name != "" && (!ioFileName.MatchString(name) || name == "." || name == "..") {
+		return "", fmt.Errorf("%s may only contain letters, digits, '.', '_' and '-'", field)
+	}
+	return name, nil
+}
+
+// checkTests runs the validator, which can include the headers in
+// includeDir, over the tests about to be stored and responds with the
+// failing tests if there are any
+func checkTests(c *gin.Context, validatorSource, validatorLang, testcaseText, includeDir string) bool {
+	if validatorSource == "" {
+		return true
+	}
+	if validatorLang == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "validator_language is required"})
+		return false
+	}
+
+	invalid, err := judge.ValidateTests(validatorSource, validatorLang, testcaseText, includeDir)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	if len(invalid) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Test validation failed", "invalid_tests": invalid})
+		return false
+	}
+	return true
+}
+
+// parseLimits reads the optional time_limit (milliseconds) and memory_limit
+// (megabytes) form fields. Missing fields are returned as 0, which means
+// the judge defaults are used.
+func parseLimits(c *gin.Context) (uint, uint, error) {
+	var limits [2]uint
+	for i, name := range []string{"time_limit", "memory_limit"} {
+		value := c.PostForm(name)
+		if value == "" {
+			continue
+		}
+		limit, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return 0, 0, fmt.Errorf("Invalid %s", name)
+		}
+		limits[i] = uint(limit)
+	}
+	return limits[0], limits[1], nil
+}
+
+// parseSolutions decodes the optional "solutions" form field, a JSON array
+// of reference solutions tagged with the verdict they are expected to get
+func parseSolutions(raw string) ([]models.ReferenceSolution, error) {
+	var solutions []models.ReferenceSolution
+	if raw == "" {
+		return solutions, nil
+	}
+	if err := json.Unmarshal([]byte(raw), &solutions); err != nil {
+		return nil, fmt.Errorf("Invalid solutions")
+	}
+	for i, solution := range solutions {
+		if solution.SourceCode == "" || solution.Language == "" {
+			return nil, fmt.Errorf("Solution %d: source_code and language are required", i+1)
+		}
+		if !judge.IsExpectedVerdict(solution.Expected) {
+			return nil, fmt.Errorf("Solution %d: expected must be one of %v", i+1, judge.ExpectedVerdicts)
+		}
+	}
+	return solutions, nil
+}
+
+// parseGenerators decodes the optional "generators" form field, a JSON array
+// of generator programs referenced by name from the generator script
+func parseGenerators(raw string) ([]models.Generator, error) {
+	var generators []models.Generator
+	if raw == "" {
+		return generators, nil
+	}
+	if err := json.Unmarshal([]byte(raw), &generators); err != nil {
+		return nil, fmt.Errorf("Invalid generators")
+	}
+	names := make(map[string]bool)
+	for i, generator := range generators {
+		if generator.Name == "" || generator.SourceCode == "" || generator.Language == "" {
+			return nil, fmt.Errorf("Generator %d: name, source_code and language are required", i+1)
+		}
+		if names[generator.Name] {
+			return nil, fmt.Errorf("Generator %d: duplicate name %s", i+1, generator.Name)
+		}
+		names[generator.Name] = true
+	}
+	return generators, nil
+}
+
+// parseHarnesses decodes the optional "harnesses" form field, a JSON array
+// with a driver and a starter template for each language the problem can
+// be solved in as a function
+func parseHarnesses(raw string) ([]models.Harness, error) {
+	var harnesses []models.Harness
+	if raw == "" {
+		return harnesses, nil
+	}
+	if err := json.Unmarshal([]byte(raw), &harnesses); err != nil {
+		return nil, fmt.Errorf("Invalid harnesses")
+	}
+	languages := make(map[string]bool)
+	for i, harness := range harnesses {
+		if harness.Language == "" || harness.Driver == "" {
+			return nil, fmt.Errorf("Harness %d: language and driver are required", i+1)
+		}
+		if languages[harness.Language] {
+			return nil, fmt.Errorf("Harness %d: duplicate language %s", i+1, harness.Language)
+		}
+		languages[harness.Language] = true
+		if !slices.ContainsFunc(strings.Split(harness.Driver, "\n"), func(line string) bool {
+			return strings.TrimSpace(line) == models.SolutionPlaceholder
+		}) {
+			return nil, fmt.Errorf("Harness %d: the driver needs a %s line where the solution goes", i+1, models.SolutionPlaceholder)
+		}
+	}
+	return harnesses, nil
 }
 
+// readValidator loads the validator stored with a problem, if it has one
+func readValidator(problem models.Problem) (string, string, error) {
+	if problem.ValidatorPath == "" {
+		return "", "", nil
+	}
+	content, err := os.ReadFile(problem.ValidatorPath)
+	if err != nil {
+		return "", "", fmt.Errorf("Failed to read validator")
+	}
+	return string(content), problem.ValidatorLanguage, nil
+}
+
+// GetProblem returns a problem as it appears in the contest given by the
+// contest_id query parameter, or else in the latest contest that uses it.
+// The statement is in the language asked for, see localize, and shows the
+// sample tests.
 func (pc *ProblemController) GetProblem(c *gin.Context) {
 	id := c.Param("problemId")
 	var problem models.Problem
-	if err := pc.Db.First(&problem, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Problem not found"})
+
+	query := withContestLinks(pc.Db).
+		Joins("JOIN contests ON contests.id = contest_problems.contest_id").
+		Where("problems.id = ?", id)
+	if contestId := c.Query("contest_id"); contestId != "" {
+		query = query.Where("contest_problems.contest_id = ?", contestId)
+	}
+	// contests that haven't started don't show their problems
+	if c.GetString("role") != "admin" {
+		query = query.Where("contests.start_time <= ?", time.Now())
+	}
+	err := query.Preload("Tags").Order("contests.start_time DESC").Limit(1).Find(&problem).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve problem"})
 		return
 	}
+
+	if problem.Id == 0 {
+		if c.Query("contest_id") != "" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Problem is not part of this contest"})
+			return
+		}
+		if err := pc.Db.Preload("Tags").First(&problem, id).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Problem not found"})
+			return
+		}
+	}
+
+	if err := pc.localize(c, &problem); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve statements"})
+		return
+	}
+	samples, err := loadSamples(problem)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	problem.Samples = samples
+	problem.Statement = problem.StatementSections.Markdown(samples)
+	// contestants only get the templates to start from
+	if c.GetString("role") != "admin" {
+		for i := range problem.Harnesses {
+			problem.Harnesses[i].Driver = ""
+		}
+	}
 	c.JSON(http.StatusOK, problem)
 }
 
@@ -91,27 +492,442 @@ func (pc *ProblemController) UpdateProblem(c *gin.Context) {
 		return
 	}
 
+	before := models.RevisionOf(problem)
 	title := c.PostForm("title")
-	statement := c.PostForm("statement")
+	testcaseText := c.PostForm("testcase")
+	solutionsText := c.PostForm("solutions")
 	if title != "" {
 		problem.Title = title
 	}
-	if statement != "" {
-		problem.Statement = statement
+	parseSections(c, &problem.StatementSections)
+	problem.Statement = problem.StatementSections.Markdown(nil)
+
+	timeLimit, memoryLimit, err := parseLimits(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if timeLimit != 0 {
+		problem.TimeLimit = timeLimit
+	}
+	if memoryLimit != 0 {
+		problem.MemoryLimit = memoryLimit
+	}
+	if c.PostForm("public") != "" {
+		if problem.Public, err = parsePublic(c); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if c.PostForm("difficulty") != "" {
+		if problem.Difficulty, err = parseDifficulty(c); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if source, ok := c.GetPostForm("source"); ok {
+		problem.Source = source
+	}
+	if c.PostForm("type") != "" {
+		if problem.Type, err = parseProblemType(c); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	// an empty file name switches back to stdin or stdout
+	if _, ok := c.GetPostForm("input_file"); ok {
+		if problem.InputFile, err = parseIOFile(c, "input_file"); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if _, ok := c.GetPostForm("output_file"); ok {
+		if problem.OutputFile, err = parseIOFile(c, "output_file"); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	language, err := parseLanguage(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if language != "" && language != problem.DefaultLanguage {
+		var translated int64
+		if err := pc.Db.Model(&models.ProblemStatement{}).Where("problem_id = ? AND language = ?", problem.Id, language).Count(&translated).Error; err != nil || translated > 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Delete the " + language + " translation before making it the default language"})
+			return
+		}
+		problem.DefaultLanguage = language
 	}
 
-	if err := pc.Db.Save(&problem).Error; err != nil {
+	solutions, err := parseSolutions(solutionsText)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if raw, ok := c.GetPostForm("harnesses"); ok {
+		if problem.Harnesses, err = parseHarnesses(raw); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	// new tests are checked against the stored validator, and a new
+	// validator against the stored tests
+	validatorSource := c.PostForm("validator")
+	validatorLang := c.PostForm("validator_language")
+	if testcaseText != "" || validatorSource != "" {
+		checkSource, checkLang, checkText := validatorSource, validatorLang, testcaseText
+		if checkSource == "" {
+			checkSource, checkLang, err = readValidator(problem)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+		}
+		if checkText == "" {
+			content, err := os.ReadFile(problem.TestCasePath)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read testcase file"})
+				return
+			}
+			checkText = string(content)
+		}
+		if !checkTests(c, checkSource, checkLang, checkText, filepath.Dir(problem.TestCasePath)) {
+			return
+		}
+	}
+
+	if raw, ok := c.GetPostForm("samples"); ok {
+		text := testcaseText
+		if text == "" {
+			content, err := os.ReadFile(problem.TestCasePath)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read testcase file"})
+				return
+			}
+			text = string(content)
+		}
+		if problem.SampleTests, err = parseSamples(raw, text); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	// the new files are written in the transaction and removed if it fails,
+	// earlier revisions keep pointing at their own files
+	var written []string
+	if testcaseText != "" {
+		problem.TestCasePath = utils.ProblemFilePath(problem.Id, utils.TestCaseFileName(problem.TestVersion+1))
+		problem.TestVersion++
+		written = append(written, problem.TestCasePath)
+	}
+	if validatorSource != "" {
+		problem.ValidatorPath = utils.ProblemFilePath(problem.Id, fmt.Sprintf("validator_r%d.%s", problem.Revision+1, validatorLang))
+		problem.ValidatorLanguage = validatorLang
+		written = append(written, problem.ValidatorPath)
+	}
+
+	// only changes to what the problem is judged with make a new revision
+	err = pc.Db.Transaction(func(tx *gorm.DB) error {
+		if testcaseText != "" {
+			if err := utils.WriteProblemFile(problem.TestCasePath, testcaseText); err != nil {
+				return err
+			}
+		}
+		if validatorSource != "" {
+			if err := utils.WriteProblemFile(problem.ValidatorPath, validatorSource); err != nil {
+				return err
+			}
+		}
+
+		var err error
+		if models.RevisionOf(problem).Same(before) {
+			err = tx.Omit("Solutions", "Generators").Save(&problem).Error
+		} else {
+			err = database.SaveRevision(tx, &problem, c.GetUint("userId"), c.PostForm("comment"))
+		}
+		if err != nil {
+			return err
+		}
+
+		// a new solutions field replaces the whole set of reference
+		// solutions
+		if solutionsText != "" {
+			if err := tx.Where("problem_id = ?", problem.Id).Delete(&models.ReferenceSolution{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&problem).Association("Solutions").Append(solutions); err != nil {
+				return err
+			}
+		}
+
+		// an empty tags field removes every tag
+		tagsText, ok := c.GetPostForm("tags")
+		if !ok {
+			return tx.Model(&problem).Association("Tags").Find(&problem.Tags)
+		}
+		if problem.Tags, err = database.FindOrCreateTags(tx, parseTags(tagsText)); err != nil {
+			return err
+		}
+		return tx.Model(&problem).Association("Tags").Replace(problem.Tags)
+	})
+	if err != nil {
+		for _, path := range written {
+			os.Remove(path)
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update problem"})
 		return
 	}
-	c.JSON(http.StatusOK, problem)
+
+	_, harnessesChanged := c.GetPostForm("harnesses")
+	verifying := false
+	if testcaseText != "" || solutionsText != "" || harnessesChanged {
+		verifying = pc.verify(c, problem.Id)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"problem": problem, "verifying": verifying})
 }
 
-func (pc *ProblemController) DeleteProblem(c *gin.Context) {
+// VerifyProblem re-runs the validator of a problem against its current
+// tests, and queues the verification of its reference solutions
+func (pc *ProblemController) VerifyProblem(c *gin.Context) {
 	id := c.Param("problemId")
-	if err := pc.Db.Delete(&models.Problem{}, id).Error; err != nil {
+	var problem models.Problem
+	if err := pc.Db.First(&problem, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Problem not found"})
+		return
+	}
+
+	validator, validatorLang, err := readValidator(problem)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	invalid := []judge.ValidationReport{}
+	if validator != "" {
+		tests, err := os.ReadFile(problem.TestCasePath)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read testcase file"})
+			return
+		}
+		invalid, err = judge.ValidateTests(validator, validatorLang, string(tests), filepath.Dir(problem.TestCasePath))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if err := judge.QueueVerification(pc.Db, problem.Id, c.GetUint("userId")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"problem": problem, "verifying": true, "invalid_tests": invalid})
+}
+
+// GetVerification reports the last verdict of each reference solution of a
+// problem and the ones that don't match their tag. pending is true while
+// some are still waiting to be judged.
+func (pc *ProblemController) GetVerification(c *gin.Context) {
+	var problem models.Problem
+	if err := pc.Db.Preload("Solutions").First(&problem, c.Param("problemId")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Problem not found"})
+		return
+	}
+	pending := slices.ContainsFunc(problem.Solutions, func(solution models.ReferenceSolution) bool {
+		return solution.Status == judge.VerificationPending
+	})
+	c.JSON(http.StatusOK, gin.H{
+		"revision":   problem.Revision,
+		"pending":    pending,
+		"solutions":  problem.Solutions,
+		"mismatches": judge.Mismatches(problem.Solutions),
+	})
+}
+
+func (pc *ProblemController) DeleteProblem(c *gin.Context) {
+	var problem models.Problem
+	if err := pc.Db.First(&problem, c.Param("problemId")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Problem not found"})
+		return
+	}
+	err := pc.Db.Transaction(func(tx *gorm.DB) error {
+		if err := deleteAttachments(tx, problem.Id); err != nil {
+			return err
+		}
+		// the rows that refer to the problem, which their foreign keys
+		// don't delete along with it
+		for _, child := range []interface{}{
+			&models.ReferenceSolution{},
+			&models.Generator{},
+			&models.ProblemStatement{},
+			&models.ProblemRevision{},
+		} {
+			if err := tx.Where("problem_id = ?", problem.Id).Delete(child).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(&problem).Association("Tags").Clear(); err != nil {
+			return err
+		}
+		return tx.Delete(&problem).Error
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete problem"})
 		return
 	}
+	if err := os.RemoveAll(utils.AttachmentDir(problem.Id)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete attachments"})
+		return
+	}
+	// the tests and validators of every revision
+	if err := os.RemoveAll(utils.ProblemDir(problem.Id)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete problem files"})
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{"message": "Problem deleted"})
 }
+
+// GenerateTests reruns the generator script of a problem and stores the
+// result as a new version of its tests. The generators and the script can
+// be replaced in the same request.
+func (pc *ProblemController) GenerateTests(c *gin.Context) {
+	id := c.Param("problemId")
+	var problem models.Problem
+	if err := pc.Db.Preload("Solutions").Preload("Generators").First(&problem, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Problem not found"})
+		return
+	}
+
+	generatorsText := c.PostForm("generators")
+	generators, err := parseGenerators(generatorsText)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if generatorsText == "" {
+		generators = problem.Generators
+	}
+
+	script := c.PostForm("script")
+	if script == "" {
+		script = problem.GeneratorScript
+	}
+	if script == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Generator script is required"})
+		return
+	}
+
+	includeDir := filepath.Dir(problem.TestCasePath)
+	testcaseText, err := judge.GenerateTests(generators, script, problem.Solutions, judge.OptionsFor(problem), includeDir)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	validator, validatorLang, err := readValidator(problem)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	testcasePath := utils.ProblemFilePath(problem.Id, utils.TestCaseFileName(problem.TestVersion+1))
+	if !checkTests(c, validator, validatorLang, testcaseText, includeDir) {
+		return
+	}
+	if err := utils.WriteProblemFile(testcasePath, testcaseText); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	problem.TestCasePath = testcasePath
+	problem.TestVersion++
+	problem.GeneratorScript = script
+
+	err = pc.Db.Transaction(func(tx *gorm.DB) error {
+		if err := database.SaveRevision(tx, &problem, c.GetUint("userId"), "Generated tests"); err != nil {
+			return err
+		}
+		if generatorsText == "" {
+			return nil
+		}
+		if err := tx.Where("problem_id = ?", problem.Id).Delete(&models.Generator{}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&problem).Association("Generators").Append(generators)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update problem"})
+		return
+	}
+
+	verifying := len(problem.Solutions) > 0 && pc.verify(c, problem.Id)
+	c.JSON(http.StatusOK, gin.H{"problem": problem, "verifying": verifying})
+}
+
+// ImportProblem creates a problem from an uploaded Polygon or Kattis
+// problem package, in the archive and optionally in a contest
+func (pc *ProblemController) ImportProblem(c *gin.Context) {
+	link, err := pc.parseContestLink(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	fileHeader, err := c.FormFile("package")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Package file is required"})
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read package"})
+		return
+	}
+	defer file.Close()
+
+	reader, err := zip.NewReader(file, fileHeader.Size)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Package must be a zip archive"})
+		return
+	}
+	pkg, err := problempkg.Read(reader)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	problem, err := problempkg.Save(pc.Db, pkg, link, c.GetUint("userId"))
+	var invalid *problempkg.InvalidTestsError
+	if errors.As(err, &invalid) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Test validation failed", "invalid_tests": invalid.Reports})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"problem": problem, "warnings": pkg.Warnings})
+}
+
+// ExportProblem downloads the problem as a problem package that can be
+// imported again
+func (pc *ProblemController) ExportProblem(c *gin.Context) {
+	id := c.Param("problemId")
+	var problem models.Problem
+	if err := pc.Db.Preload("Solutions").Preload("Generators").Preload("Tags").Preload("Statements").First(&problem, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Problem not found"})
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := problempkg.Write(&buf, problem); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=problem_%d.zip", problem.Id))
+	c.Data(http.StatusOK, "application/zip", buf.Bytes())
+}
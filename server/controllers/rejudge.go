@@ -0,0 +1,81 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/khayrultw/go-judge/judge"
+	"github.com/khayrultw/go-judge/models"
+	"gorm.io/gorm"
+)
+
+// RejudgeSubmission judges one submission again
+func (sc *SubmissionController) RejudgeSubmission(c *gin.Context) {
+	rejudge(c, sc.Db, sc.Db.Where("id = ?", c.Param("submissionId")))
+}
+
+// RejudgeProblem judges the submissions to a problem again, in every
+// contest and in the archive. The verdict query parameter picks only the
+// submissions with some verdicts, like WA or TLE,RE.
+func (pc *ProblemController) RejudgeProblem(c *gin.Context) {
+	rejudge(c, pc.Db, pc.Db.Where("problem_id = ?", c.Param("problemId")))
+}
+
+// RejudgeContest judges the submissions of a contest again, see
+// RejudgeProblem
+func (cc *ContestController) RejudgeContest(c *gin.Context) {
+	rejudge(c, cc.Db, cc.Db.Where("contest_id = ?", c.Param("contestId")))
+}
+
+// GetSubmissionHistory lists the verdicts a submission had before, newest
+// first
+func (sc *SubmissionController) GetSubmissionHistory(c *gin.Context) {
+	history := []models.SubmissionHistory{}
+	err := sc.Db.Where("submission_id = ?", c.Param("submissionId")).Order("id DESC").Find(&history).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve history"})
+		return
+	}
+	c.JSON(http.StatusOK, history)
+}
+
+func rejudge(c *gin.Context, db *gorm.DB, query *gorm.DB) {
+	if verdicts := c.Query("verdict"); verdicts != "" {
+		filter := db
+		for _, verdict := range strings.Split(verdicts, ",") {
+			condition, ok := verdictConditions[strings.TrimSpace(verdict)]
+			if !ok {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown verdict " + verdict})
+				return
+			}
+			filter = filter.Or(condition)
+		}
+		query = query.Where(filter)
+	}
+
+	// pending submissions will be judged anyway and cancelled ones were
+	// withdrawn. Overridden ones are judged again but keep the verdict an
+	// admin gave them.
+	var submissions []models.Submission
+	if err := query.Where("status NOT IN ? AND automatic_status <> ?", []string{"pending", models.StatusCancelled}, "pending").Order("id ASC").Find(&submissions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve submissions"})
+		return
+	}
+	if err := judge.Rejudge(db, submissions, c.GetUint("userId")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rejudge submissions"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"rejudging": len(submissions)})
+}
+
+// verdictConditions select the submissions with a verdict, from the status
+// judge.Verdict maps to it
+var verdictConditions = map[string]string{
+	judge.VerdictAccepted:     "status = 'PASS'",
+	judge.VerdictWrongAnswer:  "status = 'FAIL'",
+	judge.VerdictCompileError: "status = 'Syntax Error'",
+	judge.VerdictTimeLimit:    "status LIKE 'Time Limit Exceeded%'",
+	judge.VerdictMemoryLimit:  "status LIKE 'Memory Limit Exceeded%'",
+	judge.VerdictRuntimeError: "status NOT IN ('PASS', 'FAIL', 'Syntax Error', 'Disqualified', 'Cancelled', 'pending') AND status NOT LIKE 'Time Limit Exceeded%' AND status NOT LIKE 'Memory Limit Exceeded%'",
+}
@@ -0,0 +1,122 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/khayrultw/go-judge/database"
+	"github.com/khayrultw/go-judge/models"
+	"github.com/khayrultw/go-judge/utils"
+	"gorm.io/gorm"
+)
+
+// RevisionChange is a field that differs between two revisions
+type RevisionChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+// ListRevisions returns the history of a problem, newest first
+func (pc *ProblemController) ListRevisions(c *gin.Context) {
+	var revisions []models.ProblemRevision
+	err := pc.Db.Model(&models.ProblemRevision{}).
+		Select("problem_revisions.*, users.name AS author_name").
+		Joins("LEFT JOIN users ON users.id = problem_revisions.author_id").
+		Where("problem_revisions.problem_id = ?", c.Param("problemId")).
+		Order("problem_revisions.revision DESC").
+		Find(&revisions).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve revisions"})
+		return
+	}
+	c.JSON(http.StatusOK, revisions)
+}
+
+func (pc *ProblemController) GetRevision(c *gin.Context) {
+	revision, err := pc.findRevision(c.Param("problemId"), c.Param("revision"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, revision)
+}
+
+// DiffRevisions compares the revisions given by the from and to query
+// parameters: a line diff of the statements and the other fields that
+// changed
+func (pc *ProblemController) DiffRevisions(c *gin.Context) {
+	from, err := pc.findRevision(c.Param("problemId"), c.Query("from"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	to, err := pc.findRevision(c.Param("problemId"), c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	fields := []RevisionChange{
+		{"title", from.Title, to.Title},
+		{"test_version", from.TestVersion, to.TestVersion},
+		{"time_limit", from.TimeLimit, to.TimeLimit},
+		{"memory_limit", from.MemoryLimit, to.MemoryLimit},
+		{"checker_path", from.CheckerPath, to.CheckerPath},
+		{"validator_path", from.ValidatorPath, to.ValidatorPath},
+		{"type", from.Type, to.Type},
+		{"input_file", from.InputFile, to.InputFile},
+		{"output_file", from.OutputFile, to.OutputFile},
+	}
+	changes := []RevisionChange{}
+	for _, field := range fields {
+		if field.From != field.To {
+			changes = append(changes, field)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"from":      from.Revision,
+		"to":        to.Revision,
+		"statement": utils.DiffLines(from.Statement, to.Statement),
+		"changes":   changes,
+	})
+}
+
+// RollbackProblem makes an earlier revision current again. The rollback is
+// itself a new revision, so the history is never rewritten.
+func (pc *ProblemController) RollbackProblem(c *gin.Context) {
+	var problem models.Problem
+	if err := pc.Db.First(&problem, c.Param("problemId")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Problem not found"})
+		return
+	}
+	revision, err := pc.findRevision(c.Param("problemId"), c.Param("revision"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if revision.Revision == problem.Revision {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Revision is already current"})
+		return
+	}
+
+	revision.ApplyTo(&problem)
+	err = pc.Db.Transaction(func(tx *gorm.DB) error {
+		return database.SaveRevision(tx, &problem, c.GetUint("userId"), fmt.Sprintf("Rolled back to revision %d", revision.Revision))
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to roll back problem"})
+		return
+	}
+	c.JSON(http.StatusOK, problem)
+}
+
+func (pc *ProblemController) findRevision(problemId, revision string) (*models.ProblemRevision, error) {
+	var r models.ProblemRevision
+	if err := pc.Db.Where("problem_id = ? AND revision = ?", problemId, revision).First(&r).Error; err != nil {
+		return nil, fmt.Errorf("Revision not found")
+	}
+	return &r, nil
+}
@@ -0,0 +1,92 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/khayrultw/go-judge/database"
+	"github.com/khayrultw/go-judge/judge"
+	"github.com/khayrultw/go-judge/middleware"
+	"github.com/khayrultw/go-judge/models"
+	"gorm.io/gorm"
+)
+
+// maxRunInput limits the stdin of a custom run
+const maxRunInput = 1 << 20
+
+type RunController struct {
+	Db *gorm.DB
+}
+
+func NewRunController() *RunController {
+	db := database.Db
+	return &RunController{Db: db}
+}
+
+type runRequest struct {
+	ProblemId  uint   `json:"problem_id"`
+	SourceCode string `json:"source_code" binding:"required"`
+	Language   string `json:"language" binding:"required"`
+	Stdin      string `json:"stdin"`
+}
+
+// Run compiles and runs code on an input of the user's own, with the limits
+// of problem_id if it is given. Nothing is stored, so it costs no
+// submission and doesn't change the standings.
+func (rc *RunController) Run(c *gin.Context) {
+	var req runRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if len(req.SourceCode) > judge.MaxSubmissionSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("source_code must be at most %d KB", judge.MaxSubmissionSize>>10)})
+		return
+	}
+	if len(req.Stdin) > maxRunInput {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("stdin must be at most %d KB", maxRunInput>>10)})
+		return
+	}
+
+	opts := judge.Options{}
+	if req.ProblemId != 0 {
+		var problem models.Problem
+		if err := rc.Db.First(&problem, req.ProblemId).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Problem not found"})
+			return
+		}
+		visible, err := middleware.ProblemVisible(problem, c.GetString("role"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check contest"})
+			return
+		}
+		if !visible {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Contest has not started yet"})
+			return
+		}
+		if problem.Type == models.ProblemTypeOutputOnly {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Output-only problems have no code to run"})
+			return
+		}
+		opts = judge.OptionsFor(problem)
+		// the checker isn't needed without an expected answer
+		opts.CheckerPath = ""
+	}
+
+	var result judge.RunResult
+	err := judge.RunCustom(c.Request.Context(), c.GetUint("userId"), func() {
+		result = judge.CustomRun(req.SourceCode, req.Language, req.Stdin, opts)
+	})
+	if errors.Is(err, context.DeadlineExceeded) {
+		c.JSON(http.StatusRequestTimeout, gin.H{"error": "Timed out waiting for a judge worker"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "The run was cancelled before a judge worker was free"})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
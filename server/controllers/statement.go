@@ -0,0 +1,244 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/khayrultw/go-judge/judge"
+	"github.com/khayrultw/go-judge/models"
+)
+
+// languageCode accepts language tags like en, bn or pt-BR
+var languageCode = regexp.MustCompile(`^[a-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+
+// ListStatements returns the translations of a problem
+func (pc *ProblemController) ListStatements(c *gin.Context) {
+	statements := []models.ProblemStatement{}
+	if err := pc.Db.Where("problem_id = ?", c.Param("problemId")).Order("language ASC").Find(&statements).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve statements"})
+		return
+	}
+	c.JSON(http.StatusOK, statements)
+}
+
+// SaveStatement adds or replaces the translation of a problem in a
+// language other than its default one
+func (pc *ProblemController) SaveStatement(c *gin.Context) {
+	var problem models.Problem
+	if err := pc.Db.First(&problem, c.Param("problemId")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Problem not found"})
+		return
+	}
+	language := c.Param("language")
+	if !languageCode.MatchString(language) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid language"})
+		return
+	}
+	if language == problem.DefaultLanguage {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "The statement in the default language is edited with the problem"})
+		return
+	}
+
+	var body models.ProblemStatement
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	statement := models.ProblemStatement{ProblemId: problem.Id, Language: language}
+	if err := pc.Db.Where(&statement).FirstOrInit(&statement).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save statement"})
+		return
+	}
+	statement.Title = body.Title
+	statement.StatementSections = body.StatementSections
+	if err := pc.Db.Save(&statement).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save statement"})
+		return
+	}
+	c.JSON(http.StatusOK, statement)
+}
+
+func (pc *ProblemController) DeleteStatement(c *gin.Context) {
+	result := pc.Db.Where("problem_id = ? AND language = ?", c.Param("problemId"), c.Param("language")).Delete(&models.ProblemStatement{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete statement"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Statement not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Statement deleted"})
+}
+
+// parseSections applies the statement section form fields that are sent
+// to sections. A changed "statement", from clients that only edit the
+// whole statement, replaces them all as the legend.
+func parseSections(c *gin.Context, sections *models.StatementSections) {
+	if statement := c.PostForm("statement"); statement != "" && statement != sections.Markdown(nil) {
+		*sections = models.StatementSections{Legend: statement}
+	}
+	fields := []struct {
+		name    string
+		section *string
+	}{
+		{"legend", &sections.Legend},
+		{"input_format", &sections.InputFormat},
+		{"output_format", &sections.OutputFormat},
+		{"constraints", &sections.Constraints},
+		{"notes", &sections.Notes},
+	}
+	for _, field := range fields {
+		if value, ok := c.GetPostForm(field.name); ok {
+			*field.section = value
+		}
+	}
+}
+
+// parseSamples reads the "samples" form field, the comma separated numbers
+// of the tests shown as samples, and checks them against the tests
+func parseSamples(raw, testcaseText string) ([]uint, error) {
+	var samples []uint
+	if strings.TrimSpace(raw) == "" {
+		return samples, nil
+	}
+	tests, err := judge.ParseTestCases(testcaseText)
+	if err != nil {
+		return nil, err
+	}
+	for _, field := range strings.Split(raw, ",") {
+		number, err := strconv.ParseUint(strings.TrimSpace(field), 10, 32)
+		if err != nil || number == 0 || number > uint64(len(tests)) {
+			return nil, fmt.Errorf("samples must be numbers of tests between 1 and %d", len(tests))
+		}
+		if !slices.Contains(samples, uint(number)) {
+			samples = append(samples, uint(number))
+		}
+	}
+	return samples, nil
+}
+
+// loadSamples reads the sample tests of the problem. Sample numbers past the
+// last test are skipped.
+func loadSamples(problem models.Problem) ([]models.Sample, error) {
+	if len(problem.SampleTests) == 0 {
+		return nil, nil
+	}
+	content, err := os.ReadFile(problem.TestCasePath)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read testcase file")
+	}
+	tests, err := judge.ParseTestCases(string(content))
+	if err != nil {
+		return nil, err
+	}
+	var samples []models.Sample
+	for _, number := range problem.SampleTests {
+		if number >= 1 && int(number) <= len(tests) {
+			samples = append(samples, models.Sample{Input: tests[number-1].Input, Output: tests[number-1].Output})
+		}
+	}
+	return samples, nil
+}
+
+// parseLanguage reads the optional "language" form field, the default
+// language of a problem
+func parseLanguage(c *gin.Context) (string, error) {
+	language := c.PostForm("language")
+	if language != "" && !languageCode.MatchString(language) {
+		return "", fmt.Errorf("Invalid language")
+	}
+	return language, nil
+}
+
+// localize puts the statement in the language the client asked for, with
+// the lang query parameter or else the Accept-Language header, into the
+// title and statement of the problem. The default language is kept when
+// there is no translation in any of them.
+func (pc *ProblemController) localize(c *gin.Context, problem *models.Problem) error {
+	var translations []models.ProblemStatement
+	if err := pc.Db.Where("problem_id = ?", problem.Id).Order("language ASC").Find(&translations).Error; err != nil {
+		return err
+	}
+
+	problem.Language = problem.DefaultLanguage
+	problem.Languages = []string{problem.DefaultLanguage}
+	for _, t := range translations {
+		problem.Languages = append(problem.Languages, t.Language)
+	}
+
+	wanted := acceptedLanguages(c.GetHeader("Accept-Language"))
+	if lang := c.Query("lang"); lang != "" {
+		wanted = append([]string{lang}, wanted...)
+	}
+	// an exact match wins over a match of only the primary language, like
+	// bn-BD for bn
+	for _, lang := range wanted {
+		for _, match := range []func(a, b string) bool{strings.EqualFold, samePrimaryLanguage} {
+			if match(lang, problem.DefaultLanguage) {
+				return nil
+			}
+			for _, t := range translations {
+				if !match(lang, t.Language) {
+					continue
+				}
+				if t.Title != "" {
+					problem.Title = t.Title
+				}
+				problem.StatementSections = t.StatementSections
+				problem.Statement = t.Markdown(nil)
+				problem.Language = t.Language
+				return nil
+			}
+		}
+	}
+	return nil
+}
+
+// acceptedLanguages lists the languages of an Accept-Language header, most
+// preferred first
+func acceptedLanguages(header string) []string {
+	type accepted struct {
+		lang string
+		q    float64
+	}
+	var langs []accepted
+	for _, part := range strings.Split(header, ",") {
+		lang, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if lang == "" || lang == "*" {
+			continue
+		}
+		q := 1.0
+		if value, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q > 0 {
+			langs = append(langs, accepted{lang, q})
+		}
+	}
+	sort.SliceStable(langs, func(i, j int) bool { return langs[i].q > langs[j].q })
+
+	names := make([]string, len(langs))
+	for i, l := range langs {
+		names[i] = l.lang
+	}
+	return names
+}
+
+func samePrimaryLanguage(a, b string) bool {
+	primaryA, _, _ := strings.Cut(a, "-")
+	primaryB, _, _ := strings.Cut(b, "-")
+	return strings.EqualFold(primaryA, primaryB)
+}
@@ -1,10 +1,17 @@
 package controllers
 
 import (
+	"archive/zip"
 	"encoding/json"
 	"fmt"
+	"io"
+	"mime/multipart"
 	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/khayrultw/go-judge/database"
@@ -14,6 +21,12 @@ import (
 	"gorm.io/gorm"
 )
 
+// maxOutputSize limits each output submitted to an output-only problem
+const maxOutputSize = 16 << 20
+
+// outputNumber finds the test number in the name of a submitted output
+var outputNumber = regexp.MustCompile(`\d+`)
+
 type SubmissionController struct {
 	Db *gorm.DB
 }
@@ -35,7 +48,7 @@ func (sc *SubmissionController) SSEMySubmissions(c *gin.Context) {
 	c.Writer.Header().Set("Connection", "keep-alive")
 
 	client := utils.GetBroadcaster().Subscribe("mysubmissions")
-	defer close(client)
+	defer utils.GetBroadcaster().Unsubscribe("mysubmissions", client)
 
 	for {
 		select {
@@ -58,6 +71,66 @@ func (sc *SubmissionController) SSEMySubmissions(c *gin.Context) {
 	}
 }
 
+// SSESubmission streams the progress of judging a submission, ending with
+// its verdict
+func (sc *SubmissionController) SSESubmission(c *gin.Context) {
+	var submission models.Submission
+	if err := sc.Db.First(&submission, c.Param("submissionId")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Submission not found"})
+		return
+	}
+	if submission.UserId != c.GetUint("userId") && c.GetString("role") != "admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return
+	}
+
+	flusher, ok := c.Writer.(http.Flusher)
+	if !ok {
+		http.Error(c.Writer, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	c.Writer.Header().Set("Content-Type", "text/event-stream")
+	c.Writer.Header().Set("Cache-Control", "no-cache")
+	c.Writer.Header().Set("Connection", "keep-alive")
+
+	topic := judge.SubmissionTopic(submission.Id)
+	client := utils.GetBroadcaster().Subscribe(topic)
+	defer utils.GetBroadcaster().Unsubscribe(topic, client)
+
+	// read the status again now that no event can be missed
+	if err := sc.Db.First(&submission, submission.Id).Error; err != nil {
+		return
+	}
+	progress := judge.Progress{SubmissionId: submission.Id, Stage: judge.StageQueued, Position: judge.GetQueue().Position(submission.Id)}
+	if submission.Status != "pending" {
+		progress = judge.Progress{SubmissionId: submission.Id, Stage: judge.StageDone, Status: submission.Status, Message: submission.Message}
+	}
+	jsonBytes, err := json.Marshal(progress)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(c.Writer, "data: %s\n\n", jsonBytes)
+	flusher.Flush()
+	if progress.Stage == judge.StageDone {
+		return
+	}
+
+	for {
+		select {
+		case msg := <-client:
+			fmt.Fprintf(c.Writer, "data: %s\n\n", msg)
+			flusher.Flush()
+			if err := json.Unmarshal([]byte(msg), &progress); err == nil && progress.Stage == judge.StageDone {
+				return
+			}
+
+		case <-c.Done():
+			return
+		}
+	}
+}
+
 func (sc *SubmissionController) SubmitCode(c *gin.Context) {
 	problemIdStr := c.Param("problemId")
 	problemId, err := strconv.ParseUint(problemIdStr, 10, 64)
@@ -72,10 +145,119 @@ func (sc *SubmissionController) SubmitCode(c *gin.Context) {
 		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
 		return
 	}
+	switch {
+	case len(submission.Files) > 0 && submission.SourceCode != "":
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Send either source_code or files"})
+		return
+	case len(submission.Files) > 0:
+		if err := judge.CheckFiles(submission.Files); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	case submission.SourceCode == "":
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "source_code is required"})
+		return
+	}
 
-	userId := c.GetUint("userId")
-	submission.UserId = userId
 	submission.ProblemId = uint(problemId)
+	sc.submit(c, submission)
+}
+
+// SubmitArchive takes a multi-file submission as a zip: the multipart
+// "archive" file, with "language" and an optional "contest_id"
+func (sc *SubmissionController) SubmitArchive(c *gin.Context) {
+	problemId, err := strconv.ParseUint(c.Param("problemId"), 10, 64)
+	if err != nil || problemId == 0 {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Missing or invalid problem_id"})
+		return
+	}
+
+	submission := models.Submission{ProblemId: uint(problemId), Language: c.PostForm("language")}
+	if submission.Language == "" {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "language is required"})
+		return
+	}
+	if contestId := c.PostForm("contest_id"); contestId != "" {
+		id, err := strconv.ParseUint(contestId, 10, 64)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid contest_id"})
+			return
+		}
+		submission.ContestId = uint(id)
+	}
+
+	fileHeader, err := c.FormFile("archive")
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Archive is required"})
+		return
+	}
+	if submission.Files, err = unpackArchive(fileHeader); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sc.submit(c, submission)
+}
+
+// unpackArchive reads the source files of a zip. A zip of a single folder
+// is read as if the folder was the root.
+func unpackArchive(fileHeader *multipart.FileHeader) ([]models.SubmissionFile, error) {
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read archive")
+	}
+	defer file.Close()
+	r, err := zip.NewReader(file, fileHeader.Size)
+	if err != nil {
+		return nil, fmt.Errorf("The archive is not a zip file")
+	}
+
+	var files []models.SubmissionFile
+	size := 0
+	for _, f := range r.File {
+		// macOS adds resource forks under __MACOSX
+		if f.FileInfo().IsDir() || strings.HasPrefix(f.Name, "__MACOSX/") {
+			continue
+		}
+		if len(files) == judge.MaxSubmissionFiles {
+			return nil, fmt.Errorf("A submission can have at most %d files", judge.MaxSubmissionFiles)
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read %s", f.Name)
+		}
+		content, err := io.ReadAll(io.LimitReader(rc, int64(judge.MaxSubmissionSize-size+1)))
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read %s", f.Name)
+		}
+		size += len(content)
+		if size > judge.MaxSubmissionSize {
+			return nil, fmt.Errorf("The files of a submission must be at most %d KB together", judge.MaxSubmissionSize>>10)
+		}
+		files = append(files, models.SubmissionFile{Name: f.Name, Content: string(content)})
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("The archive is empty")
+	}
+
+	root, _, found := strings.Cut(files[0].Name, "/")
+	for _, file := range files {
+		if dir, _, ok := strings.Cut(file.Name, "/"); !ok || dir != root {
+			found = false
+		}
+	}
+	if found {
+		for i := range files {
+			files[i].Name = strings.TrimPrefix(files[i].Name, root+"/")
+		}
+	}
+	return files, judge.CheckFiles(files)
+}
+
+// submit stores a submission of code by the current user and judges it
+func (sc *SubmissionController) submit(c *gin.Context, submission models.Submission) {
+	submission.UserId = c.GetUint("userId")
 
 	var problem models.Problem
 	if err := sc.Db.First(&problem, submission.ProblemId).Error; err != nil {
@@ -83,26 +265,139 @@ func (sc *SubmissionController) SubmitCode(c *gin.Context) {
 		return
 	}
 
+	if problem.Type == models.ProblemTypeOutputOnly {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "This problem is solved by submitting outputs"})
+		return
+	}
+	if !sc.inContest(submission.ContestId, submission.ProblemId) {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Problem is not part of this contest"})
+		return
+	}
+
+	if len(problem.Harnesses) > 0 && problem.HarnessFor(submission.Language) == nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "This problem can't be solved in " + submission.Language})
+		return
+	}
+	if len(problem.Harnesses) > 0 && len(submission.Files) > 0 {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Submit only the function to this problem"})
+		return
+	}
+
+	submission.ProblemRevision = problem.Revision
 	if err := sc.Db.Create(&submission).Error; err != nil {
 		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err})
 		return
 	}
 
-	go func() {
-		defer judge.RunTest(database.Db, submission, problem)
-	}()
+	judge.Enqueue(database.Db, submission, problem)
+
+	c.JSON(http.StatusOK, submission)
+}
+
+// SubmitOutputs takes a submission to an output-only problem: a multipart
+// "outputs" file for each test, numbered like 1.out or test_01.txt
+func (sc *SubmissionController) SubmitOutputs(c *gin.Context) {
+	problemId, err := strconv.ParseUint(c.Param("problemId"), 10, 64)
+	if err != nil || problemId == 0 {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Missing or invalid problem_id"})
+		return
+	}
+
+	var problem models.Problem
+	if err := sc.Db.First(&problem, problemId).Error; err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Problem not found"})
+		return
+	}
+	if problem.Type != models.ProblemTypeOutputOnly {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "This problem is solved by submitting code"})
+		return
+	}
+
+	submission := models.Submission{
+		UserId:          c.GetUint("userId"),
+		ProblemId:       problem.Id,
+		Language:        models.LanguageOutput,
+		ProblemRevision: problem.Revision,
+	}
+	if contestId := c.PostForm("contest_id"); contestId != "" {
+		id, err := strconv.ParseUint(contestId, 10, 64)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid contest_id"})
+			return
+		}
+		submission.ContestId = uint(id)
+	}
+	if !sc.inContest(submission.ContestId, submission.ProblemId) {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Problem is not part of this contest"})
+		return
+	}
+
+	form, err := c.MultipartForm()
+	if err != nil || len(form.File["outputs"]) == 0 {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Outputs are required"})
+		return
+	}
+	files := make(map[int]*multipart.FileHeader)
+	for _, file := range form.File["outputs"] {
+		number, err := strconv.Atoi(outputNumber.FindString(filepath.Base(file.Filename)))
+		if err != nil || number == 0 {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "No test number in the name of " + file.Filename})
+			return
+		}
+		if _, ok := files[number]; ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("More than one output for test %d", number)})
+			return
+		}
+		if file.Size > maxOutputSize {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("Outputs must be at most %d MB", maxOutputSize>>20)})
+			return
+		}
+		files[number] = file
+	}
+
+	if err := sc.Db.Create(&submission).Error; err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to save submission"})
+		return
+	}
+	for number, file := range files {
+		if err := c.SaveUploadedFile(file, utils.SubmissionOutputPath(submission.Id, number)); err != nil {
+			// a submission without its outputs would stay pending
+			os.RemoveAll(utils.SubmissionOutputDir(submission.Id))
+			sc.Db.Delete(&submission)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to save outputs"})
+			return
+		}
+	}
+
+	judge.Enqueue(database.Db, submission, problem)
 
 	c.JSON(http.StatusOK, submission)
 }
 
+// inContest checks that the problem is part of the contest. Submissions
+// without a contest are practice on the archive.
+func (sc *SubmissionController) inContest(contestId, problemId uint) bool {
+	if contestId == 0 {
+		return true
+	}
+	var linked int64
+	err := sc.Db.Model(&models.ContestProblem{}).
+		Where("contest_id = ? AND problem_id = ?", contestId, problemId).
+		Count(&linked).Error
+	return err == nil && linked > 0
+}
+
 func (sc *SubmissionController) GetSubmission(c *gin.Context) {
 	var submission models.Submission
 	id, _ := strconv.Atoi(c.Param("submissionId"))
-	err := sc.Db.Where("id = ?", id).First(&submission).Error
+	err := sc.Db.Preload("Tests", func(db *gorm.DB) *gorm.DB {
+		return db.Order("test ASC")
+	}).Where("id = ?", id).First(&submission).Error
 	if err != nil {
 		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err})
 		return
 	}
+	submission.QueuePosition = judge.GetQueue().Position(submission.Id)
 
 	c.JSON(http.StatusOK, submission)
 }
@@ -154,3 +449,34 @@ func (sc *SubmissionController) GetSubsByUser(userId uint) ([]models.SubmissionW
 	return response, nil
 }
 
+// CancelSubmission withdraws a submission of the user that is still in the
+// judge queue. Admins can cancel any submission, even while it is judged.
+func (sc *SubmissionController) CancelSubmission(c *gin.Context) {
+	var submission models.Submission
+	if err := sc.Db.First(&submission, c.Param("submissionId")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Submission not found"})
+		return
+	}
+	admin := c.GetString("role") == "admin"
+	if submission.UserId != c.GetUint("userId") && !admin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return
+	}
+	if submission.Status != "pending" {
+		c.JSON(http.StatusConflict, gin.H{"error": "The submission is already judged"})
+		return
+	}
+
+	cancelled, err := judge.Cancel(sc.Db, submission.Id, admin)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel submission"})
+		return
+	}
+	if !cancelled {
+		c.JSON(http.StatusConflict, gin.H{"error": "Judging has already started"})
+		return
+	}
+
+	sc.Db.First(&submission, submission.Id)
+	c.JSON(http.StatusOK, submission)
+}
\ No newline at end of file
@@ -0,0 +1,132 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/khayrultw/go-judge/database"
+	"github.com/khayrultw/go-judge/judge"
+	"gorm.io/gorm"
+)
+
+// leasePoll is how long a lease request waits for a job
+const leasePoll = 30 * time.Second
+
+// WorkerController serves the remote judge workers, see judge/remote.go
+type WorkerController struct {
+	Db *gorm.DB
+}
+
+func NewWorkerController() *WorkerController {
+	db := database.Db
+	return &WorkerController{Db: db}
+}
+
+func (wc *WorkerController) Register(c *gin.Context) {
+	var body struct {
+		Name      string   `json:"name"`
+		Languages []string `json:"languages"`
+	}
+	if err := c.BindJSON(&body); err != nil {
+		return
+	}
+	if len(body.Languages) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A worker needs at least one language"})
+		return
+	}
+	c.JSON(http.StatusOK, judge.RegisterWorker(body.Name, body.Languages))
+}
+
+// Lease hands out the next job the worker can judge, waiting for one for a
+// while. It responds 204 when there is none.
+func (wc *WorkerController) Lease(c *gin.Context) {
+	var body struct {
+		WorkerId string `json:"worker_id"`
+	}
+	if err := c.BindJSON(&body); err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), leasePoll)
+	defer cancel()
+	job, err := judge.Lease(ctx, wc.Db, body.WorkerId)
+	if err != nil {
+		workerError(c, err)
+		return
+	}
+	if job == nil {
+		c.Status(http.StatusNoContent)
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+// File serves a file of a job by its hash
+func (wc *WorkerController) File(c *gin.Context) {
+	path, ok := judge.SharedFile(c.Param("hash"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return
+	}
+	c.File(path)
+}
+
+// Heartbeat renews the lease of a job the worker is judging. A worker
+// stops judging a job when the response says it is cancelled.
+func (wc *WorkerController) Heartbeat(c *gin.Context) {
+	var body struct {
+		WorkerId     string `json:"worker_id"`
+		SubmissionId uint   `json:"submission_id"`
+	}
+	if err := c.BindJSON(&body); err != nil {
+		return
+	}
+	leased, err := judge.Heartbeat(body.WorkerId, body.SubmissionId)
+	if err != nil {
+		workerError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"cancelled": !leased})
+}
+
+// Progress records the progress of a job. A worker stops judging a job
+// when the response says it is cancelled.
+func (wc *WorkerController) Progress(c *gin.Context) {
+	var progress judge.RemoteProgress
+	if err := c.BindJSON(&progress); err != nil {
+		return
+	}
+	leased, err := judge.ReportProgress(wc.Db, progress)
+	if err != nil {
+		workerError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"cancelled": !leased})
+}
+
+func (wc *WorkerController) Result(c *gin.Context) {
+	var result judge.RemoteResult
+	if err := c.BindJSON(&result); err != nil {
+		return
+	}
+	if err := judge.ReportResult(wc.Db, result); err != nil {
+		workerError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Result recorded"})
+}
+
+// ListWorkers shows the registered workers to admins
+func (wc *WorkerController) ListWorkers(c *gin.Context) {
+	c.JSON(http.StatusOK, judge.Workers())
+}
+
+func workerError(c *gin.Context, err error) {
+	if errors.Is(err, judge.ErrUnknownWorker) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
@@ -30,7 +30,26 @@ func InitDb() error {
 		&models.Contest{},
 		&models.Problem{},
 		&models.Submission{},
+		&models.ReferenceSolution{},
+		&models.Generator{},
+		&models.ContestProblem{},
+		&models.ProblemRevision{},
+		&models.Tag{},
+		&models.Attachment{},
+		&models.ProblemStatement{},
+		&models.TestResult{},
+		&models.SubmissionHistory{},
+		&models.VerdictOverride{},
 	)
+	if err := migrateContestProblems(db); err != nil {
+		log.Fatalf("Failed to migrate contest problems: %v", err)
+	}
+	if err := migrateProblemRevisions(db); err != nil {
+		log.Fatalf("Failed to migrate problem revisions: %v", err)
+	}
+	if err := migrateStatementSections(db); err != nil {
+		log.Fatalf("Failed to migrate statement sections: %v", err)
+	}
 
 	fmt.Printf("Database Connected")
 
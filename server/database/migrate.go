@@ -0,0 +1,59 @@
+package database
+
+import "gorm.io/gorm"
+
+// migrateContestProblems moves the contest each problem used to belong to
+// into contest_problems. It only does something on databases created
+// before problems could be shared between contests.
+func migrateContestProblems(db *gorm.DB) error {
+	if !db.Migrator().HasColumn("problems", "contest_id") {
+		return nil
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(`INSERT INTO contest_problems (contest_id, problem_id, label, position, created_at)
+			SELECT contest_id, id, chr(65 + problem_number), problem_number, created_at
+			FROM problems WHERE contest_id IS NOT NULL
+			ON CONFLICT DO NOTHING`).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Migrator().DropColumn("problems", "contest_id"); err != nil {
+			return err
+		}
+		return tx.Migrator().DropColumn("problems", "problem_number")
+	})
+}
+
+// migrateProblemRevisions gives problems created before revisions were
+// tracked their first revision
+func migrateProblemRevisions(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(`INSERT INTO problem_revisions (problem_id, revision, title, statement, test_case_path, test_version,
+				time_limit, memory_limit, checker_path, checker_language, checker_protocol,
+				validator_path, validator_language, author_id, comment, created_at)
+			SELECT id, 1, title, statement, test_case_path, COALESCE(test_version, 0),
+				COALESCE(time_limit, 0), COALESCE(memory_limit, 0), COALESCE(checker_path, ''), COALESCE(checker_language, ''), COALESCE(checker_protocol, ''),
+				COALESCE(validator_path, ''), COALESCE(validator_language, ''), 0, 'Initial revision', created_at
+			FROM problems WHERE revision IS NULL OR revision = 0`).Error
+		if err != nil {
+			return err
+		}
+		return tx.Exec("UPDATE problems SET revision = 1 WHERE revision IS NULL OR revision = 0").Error
+	})
+}
+
+// migrateStatementSections keeps the statements of problems created before
+// statements had sections as their legend
+func migrateStatementSections(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, table := range []string{"problems", "problem_revisions"} {
+			err := tx.Exec(`UPDATE ` + table + ` SET legend = statement
+				WHERE COALESCE(statement, '') <> '' AND COALESCE(legend, '') = '' AND COALESCE(input_format, '') = ''
+				AND COALESCE(output_format, '') = '' AND COALESCE("constraints", '') = '' AND COALESCE(notes, '') = ''`).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
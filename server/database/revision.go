@@ -0,0 +1,19 @@
+package database
+
+import (
+	"github.com/khayrultw/go-judge/models"
+	"gorm.io/gorm"
+)
+
+// SaveRevision saves the problem as its next revision. Associations of the
+// problem, like its solutions, are not saved.
+func SaveRevision(tx *gorm.DB, problem *models.Problem, authorId uint, comment string) error {
+	problem.Revision++
+	if err := tx.Omit("Solutions", "Generators").Save(problem).Error; err != nil {
+		return err
+	}
+	revision := models.RevisionOf(*problem)
+	revision.AuthorId = authorId
+	revision.Comment = comment
+	return tx.Create(&revision).Error
+}
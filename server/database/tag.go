@@ -0,0 +1,20 @@
+package database
+
+import (
+	"github.com/khayrultw/go-judge/models"
+	"gorm.io/gorm"
+)
+
+// FindOrCreateTags returns the tags with the given names, creating the ones
+// that don't exist yet
+func FindOrCreateTags(tx *gorm.DB, names []string) ([]models.Tag, error) {
+	tags := []models.Tag{}
+	for _, name := range names {
+		tag := models.Tag{Name: name}
+		if err := tx.Where("name = ?", name).FirstOrCreate(&tag).Error; err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, nil
+}
@@ -0,0 +1,76 @@
+#!/bin/bash
+
+# Builds the sources under a directory, like the files of a multi-file
+# submission, into a single program run.sh can run, and prints its path.
+# Errors name the files relative to the directory.
+
+SRC_DIR="$1"
+LANG="$2"
+BUILD_ERROR=$(mktemp /tmp/build_error-XXXXXX)
+CLASSES_DIR=""
+
+case "$LANG" in
+    cpp) EXT="" ;;
+    py) EXT=".pyz" ;;
+    kt) EXT=".kexe" ;;
+    java) EXT=".jar" ;;
+    *)
+        echo "Multi-file submissions are not supported in $LANG" >&2
+        exit 1
+        ;;
+esac
+
+COMPILED_CODE=$(mktemp /tmp/code-XXXXXX$EXT)
+
+cleanup() {
+    [[ -f "$BUILD_ERROR" ]] && rm -f "$BUILD_ERROR"
+    [[ -n "$CLASSES_DIR" ]] && rm -rf "$CLASSES_DIR"
+}
+
+trap cleanup EXIT
+
+fail() {
+    [[ -n "$1" ]] && echo "$1" >&2
+    cat "$BUILD_ERROR" >&2
+    rm -f "$COMPILED_CODE"
+    exit 1
+}
+
+cd "$SRC_DIR" || fail "Source directory not found"
+
+sources() {
+    find . -type f -name "$1" -printf '%P\n' | sort
+}
+
+if [[ "$LANG" == "cpp" ]]; then
+    mapfile -t SOURCES < <(sources '*.cpp'; sources '*.cc')
+    [[ ${#SOURCES[@]} -eq 0 ]] && fail "No .cpp files to compile"
+    g++ "${SOURCES[@]}" -I . -o "$COMPILED_CODE" 2>"$BUILD_ERROR" || fail
+
+elif [[ "$LANG" == "py" ]]; then
+    [[ -f main.py ]] || fail "main.py is required, it is run as the program"
+    python3 -m compileall -q . >"$BUILD_ERROR" 2>&1 || fail
+    cp main.py __main__.py
+    python3 -m zipapp . -o "$COMPILED_CODE" 2>"$BUILD_ERROR" || fail
+
+elif [[ "$LANG" == "kt" ]]; then
+    mapfile -t SOURCES < <(sources '*.kt')
+    [[ ${#SOURCES[@]} -eq 0 ]] && fail "No .kt files to compile"
+    kotlinc-native "${SOURCES[@]}" -o "$COMPILED_CODE" 2>"$BUILD_ERROR" || fail
+
+elif [[ "$LANG" == "java" ]]; then
+    mapfile -t SOURCES < <(sources '*.java')
+    [[ ${#SOURCES[@]} -eq 0 ]] && fail "No .java files to compile"
+    # the class with the main method is the entry point, in its package
+    MAIN_SOURCE=$(grep -l 'static[[:space:]]\+void[[:space:]]\+main' "${SOURCES[@]}" | head -n 1)
+    [[ -z "$MAIN_SOURCE" ]] && fail "No class has a main method"
+    PACKAGE=$(sed -n 's/^[[:space:]]*package[[:space:]]\+\([A-Za-z0-9_.]\+\)[[:space:]]*;.*/\1/p' "$MAIN_SOURCE" | head -n 1)
+    ENTRY=$(basename "$MAIN_SOURCE" .java)
+    [[ -n "$PACKAGE" ]] && ENTRY="$PACKAGE.$ENTRY"
+
+    CLASSES_DIR=$(mktemp -d /tmp/classes-XXXXXX)
+    javac -d "$CLASSES_DIR" "${SOURCES[@]}" 2>"$BUILD_ERROR" || fail
+    jar cfe "$COMPILED_CODE" "$ENTRY" -C "$CLASSES_DIR" . 2>"$BUILD_ERROR" || fail
+fi
+
+echo -n "$COMPILED_CODE"
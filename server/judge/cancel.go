@@ -0,0 +1,53 @@
+package judge
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/khayrultw/go-judge/models"
+	"gorm.io/gorm"
+)
+
+// Cancel withdraws a submission waiting in the queue, or, if running is
+// set, also stops judging one that started. It reports whether the
+// submission was cancelled.
+func Cancel(db *gorm.DB, submissionId uint, running bool) (bool, error) {
+	if !GetQueue().Remove(submissionId) && !(running && GetQueue().Stop(submissionId)) {
+		return false, nil
+	}
+
+	// a submission that got its verdict meanwhile stays judged
+	result := db.Model(&models.Submission{}).Where("id = ? AND status = ?", submissionId, "pending").Updates(map[string]interface{}{
+		"status":      models.StatusCancelled,
+		"finished_at": models.GetCurrentTime(),
+	})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+	PublishProgress(Progress{SubmissionId: submissionId, Stage: StageDone, Status: models.StatusCancelled})
+	PublishSubmissions()
+	return true, nil
+}
+
+var scopes atomic.Uint64
+
+// killOnCancel makes cancelling the context of cmd kill run.sh and the
+// program it runs. The program runs in a systemd scope of its own, apart
+// from run.sh, so the scope is named to be killed by name.
+func killOnCancel(cmd *exec.Cmd) {
+	unit := fmt.Sprintf("oto-judge-%d-%d", os.Getpid(), scopes.Add(1))
+	cmd.Env = append(cmd.Env, "SCOPE_UNIT="+unit)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		exec.Command("systemctl", "--user", "kill", "--signal=SIGKILL", unit+".scope").Run()
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGTERM)
+	}
+	cmd.WaitDelay = time.Second
+}
@@ -0,0 +1,44 @@
+#!/bin/bash
+
+CHECKER="$1"
+INPUT_FILE="$2"
+OUTPUT_FILE="$3"
+ANSWER_FILE="$4"
+LANG="$5"
+PROTOCOL="$6"
+CHECK_TIME_LIMIT=10      # Time limit of the checker in seconds
+
+# Exits with 0 if the output is accepted, 1 if it is rejected and 2 if the
+# checker itself failed. The checker's comment is written to stderr.
+
+case "$LANG" in
+    py) RUN_CMD="python3 $CHECKER" ;;
+    js) RUN_CMD="v8 $CHECKER" ;;
+    java) RUN_CMD="java -jar $CHECKER" ;;
+    *) RUN_CMD="$CHECKER" ;;
+esac
+
+if [[ "$PROTOCOL" == "kattis" ]]; then
+    FEEDBACK_DIR=$(mktemp -d /tmp/feedback-XXXXXX)
+    trap 'rm -rf "$FEEDBACK_DIR"' EXIT
+
+    timeout $CHECK_TIME_LIMIT $RUN_CMD "$INPUT_FILE" "$ANSWER_FILE" "$FEEDBACK_DIR/" < "$OUTPUT_FILE" >/dev/null 2>&1
+    exit_code=$?
+    [[ -f "$FEEDBACK_DIR/judgemessage.txt" ]] && cat "$FEEDBACK_DIR/judgemessage.txt" >&2
+
+    case $exit_code in
+        42) exit 0 ;;
+        43) exit 1 ;;
+        *) exit 2 ;;
+    esac
+fi
+
+timeout $CHECK_TIME_LIMIT $RUN_CMD "$INPUT_FILE" "$OUTPUT_FILE" "$ANSWER_FILE" >/dev/null
+exit_code=$?
+
+# testlib exit codes: 0 ok, 1 wrong answer, 2 presentation error, 3 fail
+case $exit_code in
+    0) exit 0 ;;
+    1|2) exit 1 ;;
+    *) exit 2 ;;
+esac
@@ -0,0 +1,98 @@
+package judge
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+type compiledChecker struct {
+	FilePath string
+	ModTime  time.Time
+}
+
+var (
+	checkersMu sync.Mutex
+	checkers   = make(map[string]compiledChecker)
+)
+
+// compileChecker compiles the checker at path once and reuses the binary
+// until the source changes. Files next to the checker, such as testlib.h,
+// can be included from it.
+func compileChecker(path, lang string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("Checker not found")
+	}
+
+	checkersMu.Lock()
+	defer checkersMu.Unlock()
+
+	if checker, ok := checkers[path]; ok && checker.ModTime.Equal(info.ModTime()) {
+		if _, err := os.Stat(checker.FilePath); err == nil {
+			return checker.FilePath, nil
+		}
+	}
+
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("Failed to read checker")
+	}
+	result, err := CompileCode(string(source), lang, filepath.Dir(path))
+	if err != nil {
+		return "", fmt.Errorf("Checker failed to compile: %s", result.Stderr)
+	}
+
+	if old, ok := checkers[path]; ok {
+		os.Remove(old.FilePath)
+	}
+	checkers[path] = compiledChecker{FilePath: result.FilePath, ModTime: info.ModTime()}
+	return result.FilePath, nil
+}
+
+// checkOutput decides whether output is an accepted answer to the test. It
+// returns the checker's comment along with the decision.
+func (o Options) checkOutput(inputFilePath, output, answer string) (bool, string, error) {
+	if o.CheckerPath == "" {
+		return strings.TrimSpace(output) == answer, "", nil
+	}
+
+	checker, err := compileChecker(o.CheckerPath, o.CheckerLanguage)
+	if err != nil {
+		return false, "", err
+	}
+
+	outputFile, err := GetTestCaseFile(output)
+	if err != nil {
+		return false, "", err
+	}
+	defer os.Remove(outputFile.Name())
+	answerFile, err := GetTestCaseFile(answer + "\n")
+	if err != nil {
+		return false, "", err
+	}
+	defer os.Remove(answerFile.Name())
+
+	protocol := o.CheckerProtocol
+	if protocol == "" {
+		protocol = CheckerTestlib
+	}
+
+	cmd := exec.Command("judge/check.sh", checker, inputFilePath, outputFile.Name(), answerFile.Name(), o.CheckerLanguage, protocol)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	err = cmd.Run()
+	comment := strings.TrimSpace(stderr.String())
+	if err == nil {
+		return true, comment, nil
+	}
+	if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+		return false, comment, nil
+	}
+	return false, comment, fmt.Errorf("Checker failed: %s", failureMessage(comment, err))
+}
@@ -1,6 +1,7 @@
 #!/bin/bash
 
 SRC_FILE=$(mktemp /tmp/code-XXXXXX.$2)
+INCLUDE_DIR="$3"        # optional directory with headers like testlib.h
 COMPILE_ERROR=$(mktemp /tmp/compile_error-XXXXXX)
 
 case "$2" in
@@ -8,6 +9,7 @@ case "$2" in
     py) EXT=".py" ;;
     kt) EXT=".kexe" ;;
     js) EXT=".js" ;;
+    java) EXT=".jar" ;;
     *) EXT=".txt" ;;
 esac
 
@@ -22,8 +24,21 @@ trap cleanup EXIT
 
 echo "$1" > "$SRC_FILE"
 
+# java files are named after their public class, so the source is built in
+# a directory of its own
+if [[ $SRC_FILE == *.java ]]; then
+    rm -f "$COMPILED_CODE"
+    JAVA_DIR=$(mktemp -d /tmp/java-XXXXXX)
+    CLASS=$(sed -n 's/.*public[[:space:]]\+\(final[[:space:]]\+\)\?class[[:space:]]\+\([A-Za-z0-9_]\+\).*/\2/p' "$SRC_FILE" | head -n 1)
+    cp "$SRC_FILE" "$JAVA_DIR/${CLASS:-Main}.java"
+    "$(dirname "$0")/build.sh" "$JAVA_DIR" java
+    status=$?
+    rm -rf "$JAVA_DIR"
+    exit $status
+fi
+
 if [[ $SRC_FILE == *.cpp ]]; then
-    g++ "$SRC_FILE" -o "$COMPILED_CODE" 2>"$COMPILE_ERROR"
+    g++ "$SRC_FILE" ${INCLUDE_DIR:+-I "$INCLUDE_DIR"} -o "$COMPILED_CODE" 2>"$COMPILE_ERROR"
 
     if [[ $? -ne 0 ]]; then 
         cat "$COMPILE_ERROR" >&2; 
@@ -0,0 +1,82 @@
+package judge
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// maxRunOutput is how much of stdout and stderr a custom run returns
+const maxRunOutput = 64 << 10
+
+// RunResult is the outcome of running code on an input of the user's own
+type RunResult struct {
+	Status   string `json:"status"` // OK or a verdict like CE or TLE
+	Stdout   string `json:"stdout"`
+	Stderr   string `json:"stderr"`
+	ExitCode int    `json:"exit_code"`
+	Usage
+}
+
+// RunOK is the status of a run that exited normally
+const RunOK = "OK"
+
+// CustomRun compiles the source the way a submission would be, harness
+// included, and runs it once on stdin with the limits of opts
+func CustomRun(sourceCode, lang, stdin string, opts Options) RunResult {
+	harness, err := opts.harness(sourceCode, lang)
+	if err != nil {
+		return RunResult{Status: VerdictCompileError, Stderr: err.Error()}
+	}
+	if harness != nil {
+		sourceCode = harness.Source
+	}
+	result, err := CompileCode(sourceCode, lang)
+	if err != nil {
+		stderr := result.Stderr
+		if harness != nil {
+			stderr = harness.MapErrors(stderr)
+		}
+		return RunResult{Status: VerdictCompileError, Stderr: truncate(stderr)}
+	}
+	defer os.Remove(result.FilePath)
+
+	inputFile, err := GetTestCaseFile(stdin)
+	if err != nil {
+		return RunResult{Status: VerdictRuntimeError, Stderr: err.Error()}
+	}
+	defer os.Remove(inputFile.Name())
+	inputFilePath, err := filepath.Abs(inputFile.Name())
+	if err != nil {
+		return RunResult{Status: VerdictRuntimeError, Stderr: "Failed to get input file path"}
+	}
+
+	opts.AllowStderr = true
+	stdout, stderr, usage, err := RunWithUsage(opts, result.FilePath, inputFilePath, lang)
+	if harness != nil {
+		stderr = harness.MapErrors(stderr)
+	}
+	run := RunResult{Status: RunOK, Stdout: truncate(stdout), Stderr: truncate(stderr), Usage: usage}
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		run.ExitCode = exitErr.ExitCode()
+		switch run.ExitCode {
+		case 124:
+			run.Status = VerdictTimeLimit
+		case 137:
+			run.Status = VerdictMemoryLimit
+		default:
+			run.Status = VerdictRuntimeError
+		}
+	} else if err != nil {
+		run.Status = VerdictRuntimeError
+		run.Stderr = err.Error()
+	}
+	return run
+}
+
+func truncate(output string) string {
+	if len(output) > maxRunOutput {
+		return output[:maxRunOutput] + "\n... (truncated)"
+	}
+	return output
+}
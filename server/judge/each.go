@@ -0,0 +1,98 @@
+package judge
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/khayrultw/go-judge/models"
+)
+
+// TestReport is the verdict of a program on one test and what the run
+// took. Message has the checker's comment or the stderr of a failed run.
+// The verdict is ERROR when the checker itself failed.
+type TestReport struct {
+	Test    int    `json:"test"`
+	Verdict string `json:"verdict"`
+	Usage
+	Message string `json:"message,omitempty"`
+}
+
+// JudgeEach compiles the source, or the files of a multi-file solution,
+// and judges it on every test instead of stopping at the first one that
+// fails. The error is the compiler's message when it doesn't compile.
+func JudgeEach(sourceCode string, files []models.SubmissionFile, lang string, tests []TestCase, opts Options) ([]TestReport, error) {
+	var result *CompileResult
+	var harness *harnessed
+	var err error
+	if len(files) > 0 {
+		result, err = CompileFiles(files, lang)
+	} else {
+		if harness, err = opts.harness(sourceCode, lang); err != nil {
+			return nil, err
+		}
+		if harness != nil {
+			sourceCode = harness.Source
+		}
+		result, err = CompileCode(sourceCode, lang)
+	}
+	if err != nil {
+		message := result.Stderr
+		if harness != nil {
+			message = harness.MapErrors(message)
+		}
+		return nil, fmt.Errorf("%s", message)
+	}
+	defer os.Remove(result.FilePath)
+
+	reports := make([]TestReport, len(tests))
+	for i, tc := range tests {
+		reports[i] = judgeTest(result.FilePath, lang, tc, opts, harness)
+	}
+	return reports, nil
+}
+
+func judgeTest(compiledPath, lang string, tc TestCase, opts Options, harness *harnessed) TestReport {
+	report := TestReport{Test: tc.Number}
+	inputFile, err := GetTestCaseFile(tc.Input)
+	if err != nil {
+		report.Verdict, report.Message = "ERROR", err.Error()
+		return report
+	}
+	defer os.Remove(inputFile.Name())
+	inputFilePath, err := filepath.Abs(inputFile.Name())
+	if err != nil {
+		report.Verdict, report.Message = "ERROR", err.Error()
+		return report
+	}
+
+	stdout, stderr, usage, err := RunWithUsage(opts, compiledPath, inputFilePath, lang)
+	report.Usage = usage
+	if err != nil {
+		if harness != nil {
+			stderr = harness.MapErrors(stderr)
+		}
+		report.Verdict, report.Message = VerdictRuntimeError, stderr
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			switch exitErr.ExitCode() {
+			case 124:
+				report.Verdict = VerdictTimeLimit
+			case 137:
+				report.Verdict = VerdictMemoryLimit
+			}
+		}
+		return report
+	}
+
+	accepted, comment, err := opts.checkOutput(inputFilePath, stdout, tc.Output)
+	switch {
+	case err != nil:
+		report.Verdict, report.Message = "ERROR", err.Error()
+	case accepted:
+		report.Verdict, report.Message = VerdictAccepted, comment
+	default:
+		report.Verdict, report.Message = VerdictWrongAnswer, comment
+	}
+	return report
+}
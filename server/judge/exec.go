@@ -7,6 +7,8 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/khayrultw/go-judge/models"
 )
@@ -16,8 +18,18 @@ type CompileResult struct {
 	Stderr   string
 }
 
-func CompileCode(sourceCode, lang string) (*CompileResult, error) {
-	cmd := exec.Command("judge/compile.sh", sourceCode, lang)
+// CompileCode compiles the source with compile.sh. An include directory can
+// be given for sources that include headers like testlib.h.
+func CompileCode(sourceCode, lang string, includeDir ...string) (*CompileResult, error) {
+	args := []string{sourceCode, lang}
+	if len(includeDir) > 0 && includeDir[0] != "" {
+		dir, err := filepath.Abs(includeDir[0])
+		if err != nil {
+			return &CompileResult{Stderr: err.Error()}, err
+		}
+		args = append(args, dir)
+	}
+	cmd := exec.Command("judge/compile.sh", args...)
 
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
@@ -32,17 +44,41 @@ func CompileCode(sourceCode, lang string) (*CompileResult, error) {
 }
 
 func JudgeCode(sourceCode string, testCaseFilePath string, lang string) models.Result {
+	return JudgeWithOptions(sourceCode, testCaseFilePath, lang, Options{})
+}
 
+// JudgeWithOptions judges the source against every test in the testcase
+// file, stopping at the first test that fails
+func JudgeWithOptions(sourceCode string, testCaseFilePath string, lang string, opts Options) models.Result {
+	harness, err := opts.harness(sourceCode, lang)
+	if err != nil {
+		return models.Result{Status: "Syntax Error", Message: err.Error()}
+	}
+	if harness != nil {
+		sourceCode = harness.Source
+	}
+
+	opts.progress(StageCompiling, 0, 0)
 	result, err := CompileCode(sourceCode, lang)
 	if err != nil {
+		message := result.Stderr
+		if harness != nil {
+			message = harness.MapErrors(message)
+		}
 		return models.Result{
 			Status:  "Syntax Error",
-			Message: result.Stderr,
+			Message: message,
 		}
 	}
+	return judgeCompiled(result.FilePath, testCaseFilePath, lang, opts, harness)
+}
 
+// judgeCompiled judges a compiled program and removes it afterwards.
+// Runtime errors of a program built with a harness point at the lines of
+// the submitted code.
+func judgeCompiled(compiledPath, testCaseFilePath, lang string, opts Options, harness *harnessed) models.Result {
 	defer func() {
-		err := os.Remove(result.FilePath)
+		err := os.Remove(compiledPath)
 		if err != nil {
 			fmt.Println("Failed to remove:", err)
 		}
@@ -53,43 +89,47 @@ func JudgeCode(sourceCode string, testCaseFilePath string, lang string) models.R
 		return models.Result{Status: "ERROR", Message: "Test Case File Error"}
 	}
 
-	testCases := strings.Split(string(content), "#TEST_CASE_SEP#")
+	testCases, err := ParseTestCases(string(content))
+	if err != nil {
+		return models.Result{Status: "FAIL", Message: err.Error()}
+	}
 
-	for idx, tc := range testCases {
-		tc = strings.TrimSpace(tc)
-		if tc == "" {
-			continue
-		}
-		parts := strings.Split(tc, "#IN_OUT_SEP#")
-		if len(parts) != 2 {
-			return models.Result{Status: "FAIL", Message: fmt.Sprintf("Test case %d: Invalid format", idx+1)}
+	for i, tc := range testCases {
+		if opts.context().Err() != nil {
+			return models.Result{Status: models.StatusCancelled}
 		}
-		input := strings.TrimSpace(parts[0])
-		expectedOutput := strings.TrimSpace(parts[1])
+		opts.progress(StageRunning, i+1, len(testCases))
+		idx := tc.Number - 1
+		input := tc.Input
+		expectedOutput := tc.Output
 
 		inputFile, err := GetTestCaseFile(input)
-		defer os.Remove(inputFile.Name())
 		if err != nil {
 			return models.Result{Status: "ERROR", Message: "Failed to create input file"}
 		}
+		defer os.Remove(inputFile.Name())
 
 		inputFilePath, err := filepath.Abs(inputFile.Name())
 		if err != nil {
 			return models.Result{Status: "ERROR", Message: "Failed to get input file path"}
 		}
 
-		cmd := exec.Command("judge/run.sh", result.FilePath, inputFilePath, lang)
-		var stdout, stderr bytes.Buffer
-		cmd.Stdout = &stdout
-		cmd.Stderr = &stderr
-		err = cmd.Run()
+		stdout, stderr, err := RunWithOptions(opts, compiledPath, inputFilePath, lang)
 		if err != nil {
-			return prepareErrorMessage(err, stderr.String(), idx)
+			if harness != nil {
+				stderr = harness.MapErrors(stderr)
+			}
+			return prepareErrorMessage(err, stderr, idx)
 		}
 
-		actualOutput := strings.TrimSpace(stdout.String())
+		actualOutput := strings.TrimSpace(stdout)
+
+		accepted, comment, err := opts.checkOutput(inputFilePath, stdout, expectedOutput)
+		if err != nil {
+			return models.Result{Status: "ERROR", Message: err.Error()}
+		}
 
-		if actualOutput != expectedOutput {
+		if !accepted {
 			htmlMsg := fmt.Sprintf(
 				"Failed on Test Case %d\n\nInput:\n```text\n%s\n```\n\nOutput:\n```text\n%s\n```\n\nExpected:\n```text\n%s\n```",
 				idx+1,
@@ -97,6 +137,9 @@ func JudgeCode(sourceCode string, testCaseFilePath string, lang string) models.R
 				actualOutput,
 				expectedOutput,
 			)
+			if comment != "" {
+				htmlMsg += fmt.Sprintf("\n\nChecker:\n```text\n%s\n```", comment)
+			}
 			return models.Result{Status: "FAIL", Message: htmlMsg}
 		}
 	}
@@ -104,6 +147,47 @@ func JudgeCode(sourceCode string, testCaseFilePath string, lang string) models.R
 	return models.Result{Status: "PASS", Message: ""}
 }
 
+// RunCompiled runs a compiled program inside the sandbox of run.sh with
+// inputFilePath as its stdin and args as its command line arguments
+func RunCompiled(compiledPath, inputFilePath, lang string, args ...string) (string, string, error) {
+	return RunWithOptions(Options{}, compiledPath, inputFilePath, lang, args...)
+}
+
+// RunWithOptions is RunCompiled with the time and memory limits of opts
+func RunWithOptions(opts Options, compiledPath, inputFilePath, lang string, args ...string) (string, string, error) {
+	stdout, stderr, _, err := RunWithUsage(opts, compiledPath, inputFilePath, lang, args...)
+	return stdout, stderr, err
+}
+
+// Usage is what a run took
+type Usage struct {
+	Time   uint `json:"time"`   // wall clock milliseconds
+	Memory uint `json:"memory"` // peak resident kilobytes
+}
+
+// RunWithUsage is RunWithOptions that also measures the run. The memory is
+// the peak of run.sh and everything it started, the program included.
+func RunWithUsage(opts Options, compiledPath, inputFilePath, lang string, args ...string) (string, string, Usage, error) {
+	cmd := exec.CommandContext(opts.context(), "judge/run.sh", append([]string{compiledPath, inputFilePath, lang}, args...)...)
+	cmd.Env = append(os.Environ(), opts.env()...)
+	if opts.Context != nil {
+		killOnCancel(cmd)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	start := time.Now()
+	err := cmd.Run()
+
+	usage := Usage{Time: uint(time.Since(start).Milliseconds())}
+	if cmd.ProcessState != nil {
+		if rusage, ok := cmd.ProcessState.SysUsage().(*syscall.Rusage); ok {
+			usage.Memory = uint(rusage.Maxrss)
+		}
+	}
+	return stdout.String(), stderr.String(), usage, err
+}
+
 func prepareErrorMessage(err error, errorOut string, testNumber int) models.Result {
 	if len(errorOut) > 200 {
 		errorOut = errorOut[:200] + "..."
@@ -0,0 +1,96 @@
+package judge
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/khayrultw/go-judge/models"
+)
+
+// The limits of a multi-file submission
+const (
+	MaxSubmissionFiles = 100
+	MaxSubmissionSize  = 1 << 20 // bytes, of all the files together
+)
+
+// filePathPart is what each directory and file name of a submitted file
+// may look like
+var filePathPart = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9._-]*$`)
+
+// CheckFiles checks the names and sizes of the files of a multi-file
+// submission, so that they can be written under a directory safely
+func CheckFiles(files []models.SubmissionFile) error {
+	if len(files) > MaxSubmissionFiles {
+		return fmt.Errorf("A submission can have at most %d files", MaxSubmissionFiles)
+	}
+	size := 0
+	names := make(map[string]bool)
+	for _, file := range files {
+		for _, part := range strings.Split(file.Name, "/") {
+			if !filePathPart.MatchString(part) {
+				return fmt.Errorf("Invalid file name %q", file.Name)
+			}
+		}
+		if names[file.Name] {
+			return fmt.Errorf("Duplicate file %s", file.Name)
+		}
+		names[file.Name] = true
+		size += len(file.Content)
+	}
+	if size > MaxSubmissionSize {
+		return fmt.Errorf("The files of a submission must be at most %d KB together", MaxSubmissionSize>>10)
+	}
+	return nil
+}
+
+// CompileFiles builds the files of a multi-file submission into one
+// program with build.sh, which has a recipe for each language
+func CompileFiles(files []models.SubmissionFile, lang string) (*CompileResult, error) {
+	if err := CheckFiles(files); err != nil {
+		return &CompileResult{Stderr: err.Error()}, err
+	}
+	dir, err := os.MkdirTemp("", "src-")
+	if err != nil {
+		return &CompileResult{Stderr: "Failed to create source directory"}, err
+	}
+	defer os.RemoveAll(dir)
+
+	for _, file := range files {
+		name := filepath.Join(dir, filepath.FromSlash(path.Clean(file.Name)))
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			return &CompileResult{Stderr: "Failed to write " + file.Name}, err
+		}
+		if err := os.WriteFile(name, []byte(file.Content), 0644); err != nil {
+			return &CompileResult{Stderr: "Failed to write " + file.Name}, err
+		}
+	}
+
+	cmd := exec.Command("judge/build.sh", dir, lang)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err = cmd.Run()
+	return &CompileResult{
+		FilePath: stdout.String(),
+		Stderr:   stderr.String(),
+	}, err
+}
+
+// JudgeFiles is JudgeWithOptions for a multi-file submission
+func JudgeFiles(files []models.SubmissionFile, testCaseFilePath string, lang string, opts Options) models.Result {
+	if len(opts.Harnesses) > 0 {
+		return models.Result{Status: "Syntax Error", Message: "Function problems take a single file"}
+	}
+	opts.progress(StageCompiling, 0, 0)
+	result, err := CompileFiles(files, lang)
+	if err != nil {
+		return models.Result{Status: "Syntax Error", Message: result.Stderr}
+	}
+	return judgeCompiled(result.FilePath, testCaseFilePath, lang, opts, nil)
+}
@@ -0,0 +1,185 @@
+package judge
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/khayrultw/go-judge/models"
+)
+
+type GeneratorCall struct {
+	Line   int
+	Name   string
+	Args   []string
+	Target string
+	Test   int // the number of the test, from Target
+}
+
+// ParseGeneratorScript reads a script with one generator call per line, like
+// "gen 1 100 > 5.in". Empty lines and lines starting with # are skipped.
+// The calls are returned by test number, which must run from 1 without
+// gaps.
+func ParseGeneratorScript(script string) ([]GeneratorCall, error) {
+	var calls []GeneratorCall
+	targets := make(map[int]bool)
+	for idx, line := range strings.Split(script, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		command, target, found := strings.Cut(line, ">")
+		target = strings.TrimSpace(target)
+		if !found || target == "" {
+			return nil, fmt.Errorf("Line %d: missing output file", idx+1)
+		}
+		fields := strings.Fields(command)
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("Line %d: missing generator", idx+1)
+		}
+		test, err := strconv.Atoi(strings.TrimSuffix(target, filepath.Ext(target)))
+		if err != nil || test < 1 {
+			return nil, fmt.Errorf("Line %d: output file %s is not a test number like 5.in", idx+1, target)
+		}
+		if targets[test] {
+			return nil, fmt.Errorf("Line %d: test %d is generated twice", idx+1, test)
+		}
+		targets[test] = true
+		calls = append(calls, GeneratorCall{
+			Line:   idx + 1,
+			Name:   fields[0],
+			Args:   fields[1:],
+			Target: target,
+			Test:   test,
+		})
+	}
+	if len(calls) == 0 {
+		return nil, fmt.Errorf("Generator script is empty")
+	}
+	slices.SortFunc(calls, func(a, b GeneratorCall) int { return cmp.Compare(a.Test, b.Test) })
+	for i, call := range calls {
+		if call.Test != i+1 {
+			return nil, fmt.Errorf("Test %d is not generated, the tests must be numbered from 1 without gaps", i+1)
+		}
+	}
+	return calls, nil
+}
+
+// GenerateTests runs the generator script to produce the test inputs and
+// the first expected-AC reference solution to produce their answers. The
+// result is in the same format as an uploaded testcase file, with the tests
+// numbered like their output files. Headers in includeDir can be included
+// by the generators. The solution is run like submissions, with the limits,
+// harness and input and output files of opts.
+func GenerateTests(generators []models.Generator, script string, solutions []models.ReferenceSolution, opts Options, includeDir string) (string, error) {
+	calls, err := ParseGeneratorScript(script)
+	if err != nil {
+		return "", err
+	}
+
+	var solution *models.ReferenceSolution
+	for i := range solutions {
+		if solutions[i].Expected == VerdictAccepted {
+			solution = &solutions[i]
+			break
+		}
+	}
+	if solution == nil {
+		return "", fmt.Errorf("An expected-AC reference solution is required to generate answers")
+	}
+
+	byName := make(map[string]models.Generator)
+	for _, g := range generators {
+		byName[g.Name] = g
+	}
+	for _, call := range calls {
+		if _, ok := byName[call.Name]; !ok {
+			return "", fmt.Errorf("Line %d: unknown generator %s", call.Line, call.Name)
+		}
+	}
+
+	compiled := make(map[string]string)
+	defer func() {
+		for _, path := range compiled {
+			os.Remove(path)
+		}
+	}()
+	for name, g := range byName {
+		result, err := CompileCode(g.SourceCode, g.Language, includeDir)
+		if err != nil {
+			return "", fmt.Errorf("Generator %s failed to compile: %s", name, result.Stderr)
+		}
+		compiled[name] = result.FilePath
+	}
+
+	harness, err := opts.harness(solution.SourceCode, solution.Language)
+	if err != nil {
+		return "", fmt.Errorf("Reference solution: %v", err)
+	}
+	solutionSource := solution.SourceCode
+	if harness != nil {
+		solutionSource = harness.Source
+	}
+	solutionResult, err := CompileCode(solutionSource, solution.Language)
+	if err != nil {
+		if harness != nil {
+			solutionResult.Stderr = harness.MapErrors(solutionResult.Stderr)
+		}
+		return "", fmt.Errorf("Reference solution failed to compile: %s", solutionResult.Stderr)
+	}
+	defer os.Remove(solutionResult.FilePath)
+
+	emptyInput, err := GetTestCaseFile("")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(emptyInput.Name())
+	emptyInputPath, err := filepath.Abs(emptyInput.Name())
+	if err != nil {
+		return "", err
+	}
+
+	var tests []TestCase
+	for _, call := range calls {
+		g := byName[call.Name]
+		stdout, stderr, err := RunCompiled(compiled[call.Name], emptyInputPath, g.Language, call.Args...)
+		if err != nil {
+			return "", fmt.Errorf("%s: generator failed: %s", call.Target, failureMessage(stderr, err))
+		}
+		input := strings.TrimSpace(stdout)
+
+		inputFile, err := GetTestCaseFile(input)
+		if err != nil {
+			return "", err
+		}
+		inputFilePath, err := filepath.Abs(inputFile.Name())
+		if err != nil {
+			os.Remove(inputFile.Name())
+			return "", err
+		}
+		answer, stderr, err := RunWithOptions(opts, solutionResult.FilePath, inputFilePath, solution.Language)
+		os.Remove(inputFile.Name())
+		if err != nil {
+			return "", fmt.Errorf("%s: reference solution failed: %s", call.Target, failureMessage(stderr, err))
+		}
+
+		tests = append(tests, TestCase{
+			Number: call.Test,
+			Input:  input,
+			Output: strings.TrimSpace(answer),
+		})
+	}
+
+	return FormatTestCases(tests), nil
+}
+
+func failureMessage(stderr string, err error) string {
+	if msg := strings.TrimSpace(stderr); msg != "" {
+		return msg
+	}
+	return err.Error()
+}
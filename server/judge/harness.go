@@ -0,0 +1,103 @@
+package judge
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/khayrultw/go-judge/models"
+)
+
+// harnessed is submitted code placed inside the driver of a harness
+type harnessed struct {
+	Source string
+	// the solution takes lines First to First+Lines-1 of Source
+	First int
+	Lines int
+}
+
+// combineHarness replaces the placeholder line of the driver with the
+// submitted code
+func combineHarness(driver, code string) (harnessed, error) {
+	lines := strings.Split(driver, "\n")
+	for i, line := range lines {
+		if strings.TrimSpace(line) != models.SolutionPlaceholder {
+			continue
+		}
+		code = strings.TrimRight(code, "\n")
+		source := strings.Join(lines[:i], "\n")
+		if i > 0 {
+			source += "\n"
+		}
+		source += code + "\n" + strings.Join(lines[i+1:], "\n")
+		return harnessed{Source: source, First: i + 1, Lines: strings.Count(code, "\n") + 1}, nil
+	}
+	return harnessed{}, fmt.Errorf("The driver has no %s line", models.SolutionPlaceholder)
+}
+
+// compilerLocation matches the file and line compile.sh's compilers report,
+// like /tmp/code-123.cpp:7:3 or File "/tmp/code-123.py", line 7, or javac
+// with the file build.sh names after the class, like Main.java:7, and
+// sourceExcerpt the line numbers g++ puts before the code it quotes
+var (
+	compilerLocation = regexp.MustCompile(`(?:[^\s"]*code-\w+\.(cpp|py|kt|js|java)|\b[A-Za-z_$][\w$]*\.(java))(:|", line )(\d+)`)
+	compilerFile     = regexp.MustCompile(`[^\s"]*code-\w+\.(cpp|py|kt|js|java)`)
+	sourceExcerpt    = regexp.MustCompile(`(?m)^(\s*)(\d+)( \| )`)
+)
+
+// MapErrors rewrites the locations in compiler output from lines of the
+// combined source to lines of the submitted code, and to lines of the
+// harness for errors outside of it
+func (h harnessed) MapErrors(output string) string {
+	output = compilerLocation.ReplaceAllStringFunc(output, func(location string) string {
+		match := compilerLocation.FindStringSubmatch(location)
+		ext := match[1] + match[2]
+		line, _ := strconv.Atoi(match[4])
+		inSolution, line := h.mapLine(line)
+		file := "harness." + ext
+		if inSolution {
+			file = "solution." + ext
+		}
+		if match[3] == ":" {
+			return fmt.Sprintf("%s:%d", file, line)
+		}
+		return fmt.Sprintf("%s\", line %d", file, line)
+	})
+	output = sourceExcerpt.ReplaceAllStringFunc(output, func(excerpt string) string {
+		match := sourceExcerpt.FindStringSubmatch(excerpt)
+		line, _ := strconv.Atoi(match[2])
+		_, line = h.mapLine(line)
+		return fmt.Sprintf("%s%d%s", match[1], line, match[3])
+	})
+	// the remaining mentions of the file, like "In function", are about
+	// the combined source as a whole
+	return compilerFile.ReplaceAllString(output, "solution.$1")
+}
+
+// mapLine maps a line of the combined source to a line of the solution, or
+// of the driver if it isn't in the solution
+func (h harnessed) mapLine(line int) (bool, int) {
+	switch {
+	case line < h.First:
+		return false, line
+	case line >= h.First+h.Lines:
+		return false, line - h.Lines + 1
+	}
+	return true, line - h.First + 1
+}
+
+// harness combines the code with the driver for lang, if the problem has
+// one. A problem with harnesses can't be solved in other languages.
+func (o Options) harness(code, lang string) (*harnessed, error) {
+	if len(o.Harnesses) == 0 {
+		return nil, nil
+	}
+	for _, harness := range o.Harnesses {
+		if harness.Language == lang {
+			h, err := combineHarness(harness.Driver, code)
+			return &h, err
+		}
+	}
+	return nil, fmt.Errorf("This problem can't be solved in %s", lang)
+}
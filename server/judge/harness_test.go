@@ -0,0 +1,76 @@
+package judge
+
+import "testing"
+
+func TestMapErrors(t *testing.T) {
+	// the solution is lines 3 and 4 of the combined source
+	h := harnessed{First: 3, Lines: 2}
+	tests := []struct {
+		name   string
+		output string
+		want   string
+	}{
+		{
+			name:   "g++ error in the solution",
+			output: "/tmp/code-a1B2c3.cpp:4:5: error: expected ';' before '}' token",
+			want:   "solution.cpp:2:5: error: expected ';' before '}' token",
+		},
+		{
+			name:   "g++ error in the driver after the solution",
+			output: "/tmp/code-a1B2c3.cpp:7:1: error: 'solve' was not declared in this scope",
+			want:   "harness.cpp:6:1: error: 'solve' was not declared in this scope",
+		},
+		{
+			name:   "g++ error in the driver before the solution",
+			output: "/tmp/code-a1B2c3.cpp:1:10: fatal error: bits/stdc++.hh: No such file or directory",
+			want:   "harness.cpp:1:10: fatal error: bits/stdc++.hh: No such file or directory",
+		},
+		{
+			name:   "g++ source excerpt",
+			output: "/tmp/code-a1B2c3.cpp:3:9: error: 'x' was not declared\n    3 |     return x;\n      |            ^",
+			want:   "solution.cpp:1:9: error: 'x' was not declared\n    1 |     return x;\n      |            ^",
+		},
+		{
+			name:   "g++ mentions the file without a line",
+			output: "/tmp/code-a1B2c3.cpp: In function 'int solve()':",
+			want:   "solution.cpp: In function 'int solve()':",
+		},
+		{
+			name:   "python",
+			output: "  File \"/tmp/code-a1B2c3.py\", line 4\n    return\n    ^\nSyntaxError: invalid syntax",
+			want:   "  File \"solution.py\", line 2\n    return\n    ^\nSyntaxError: invalid syntax",
+		},
+		{
+			name:   "kotlin",
+			output: "/tmp/code-a1B2c3.kt:3:13: error: unresolved reference: y",
+			want:   "solution.kt:1:13: error: unresolved reference: y",
+		},
+		{
+			name:   "node",
+			output: "/tmp/code-a1B2c3.js:5\n})\n^",
+			want:   "harness.js:4\n})\n^",
+		},
+		{
+			name:   "javac with the file named after the class",
+			output: "Main.java:4: error: ';' expected\n        return 0\n                ^\n1 error",
+			want:   "solution.java:2: error: ';' expected\n        return 0\n                ^\n1 error",
+		},
+		{
+			name:   "javac error in the driver",
+			output: "Solution.java:9: error: cannot find symbol",
+			want:   "harness.java:8: error: cannot find symbol",
+		},
+		{
+			name:   "java names that are not locations",
+			output: "import com.example.java; is not a location",
+			want:   "import com.example.java; is not a location",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := h.MapErrors(tt.output); got != tt.want {
+				t.Errorf("MapErrors() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
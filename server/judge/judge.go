@@ -1,22 +1,108 @@
 package judge
 
 import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
 	"github.com/khayrultw/go-judge/models"
 	"github.com/khayrultw/go-judge/utils"
 	"gorm.io/gorm"
 )
 
+// RunTest judges the submission, publishing its progress on the way, and
+// stores the verdict
 func RunTest(db *gorm.DB, submission models.Submission, problem models.Problem) {
-	result := JudgeCode(submission.SourceCode, problem.TestCasePath, submission.Language)
-	submission.Status = result.Status
-	submission.Message = result.Message
+	RunTestContext(context.Background(), db, submission, problem)
+}
+
+// RunTestContext is RunTest that stops judging, without a verdict, once ctx
+// is done
+func RunTestContext(ctx context.Context, db *gorm.DB, submission models.Submission, problem models.Problem) {
+	opts := OptionsFor(problem)
+	opts.Context = ctx
+	opts.Progress = func(stage string, test, tests int) {
+		recordProgress(db, submission.Id, stage, test, tests)
+	}
+
+	var result models.Result
+	var tests []models.TestResult
+	if problem.Type == models.ProblemTypeOutputOnly {
+		result, tests = JudgeOutputs(submittedOutputs(submission.Id), problem.TestCasePath, opts)
+	} else if len(submission.Files) > 0 {
+		result = JudgeFiles(submission.Files, problem.TestCasePath, submission.Language, opts)
+	} else {
+		result = JudgeWithOptions(submission.SourceCode, problem.TestCasePath, submission.Language, opts)
+	}
+	if ctx.Err() != nil {
+		return
+	}
+	recordVerdict(db, submission, problem, result, tests)
+}
+
+// recordProgress stores when the judge started to compile and to run the
+// submission and publishes the progress
+func recordProgress(db *gorm.DB, submissionId uint, stage string, test, tests int) {
+	submission := models.Submission{Id: submissionId}
+	switch {
+	case stage == StageCompiling:
+		db.Model(&submission).Update("compile_started_at", models.GetCurrentTime())
+	case stage == StageRunning && test == 1:
+		db.Model(&submission).Update("run_started_at", models.GetCurrentTime())
+	}
+	PublishProgress(Progress{SubmissionId: submissionId, Stage: stage, Test: test, Tests: tests})
+}
+
+// recordVerdict stores the verdict of the submission, with the verdict of
+// each test for output-only problems, and publishes it. The verdict of an
+// overridden submission goes to its automatic status.
+func recordVerdict(db *gorm.DB, submission models.Submission, problem models.Problem, result models.Result, tests []models.TestResult) {
+	for i := range tests {
+		tests[i].Id = 0
+		tests[i].SubmissionId = submission.Id
+	}
+	if len(tests) > 0 {
+		db.Create(&tests)
+	}
 	db.Model(&submission).Updates(map[string]interface{}{
-		"status":  result.Status,
-		"message": result.Message,
+		"status":           gorm.Expr("CASE WHEN automatic_status = '' THEN ? ELSE status END", result.Status),
+		"automatic_status": gorm.Expr("CASE WHEN automatic_status = '' THEN '' ELSE ? END", result.Status),
+		"message":          result.Message,
+		"problem_revision": problem.Revision,
+		"finished_at":      models.GetCurrentTime(),
 	})
+	status := result.Status
+	if err := db.Select("status").First(&submission, submission.Id).Error; err == nil {
+		status = submission.Status
+	}
+	PublishProgress(Progress{SubmissionId: submission.Id, Stage: StageDone, Status: status, Message: result.Message})
+	PublishSubmissions()
+}
+
+// PublishSubmissions tells the submission lists and standings to refresh
+func PublishSubmissions() {
 	utils.GetBroadcaster().Publish("all_submissions", "new submission")
 	utils.GetBroadcaster().Publish("mysubmissions", "new submission")
 	utils.GetBroadcaster().Publish("standings", "new submission")
 	utils.GetBroadcaster().Publish("contest_submissions", "new submission")
 	utils.GetBroadcaster().Publish("my_contest_submissions", "new submission")
 }
+
+// submittedOutputs reads the outputs stored for a submission to an
+// output-only problem, by test number
+func submittedOutputs(submissionId uint) map[int]string {
+	outputs := make(map[int]string)
+	paths, _ := filepath.Glob(filepath.Join(utils.SubmissionOutputDir(submissionId), "*.out"))
+	for _, path := range paths {
+		number, err := strconv.Atoi(strings.TrimSuffix(filepath.Base(path), ".out"))
+		if err != nil {
+			continue
+		}
+		if content, err := os.ReadFile(path); err == nil {
+			outputs[number] = string(content)
+		}
+	}
+	return outputs
+}
@@ -0,0 +1,80 @@
+package judge
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/khayrultw/go-judge/models"
+)
+
+const (
+	CheckerTestlib = "testlib"
+	CheckerKattis  = "kattis"
+)
+
+// The limits run.sh uses when a problem doesn't set its own
+const (
+	DefaultTimeLimit   = 2500 // milliseconds
+	DefaultMemoryLimit = 512  // megabytes
+)
+
+// Options are the per-problem settings code is judged with. The zero value
+// uses the default limits of run.sh and compares outputs exactly.
+type Options struct {
+	TimeLimit       uint // milliseconds
+	MemoryLimit     uint // megabytes
+	CheckerPath     string
+	CheckerLanguage string
+	CheckerProtocol string
+	Harnesses       []models.Harness
+	InputFile       string // read instead of stdin, if set
+	OutputFile      string // written instead of stdout, if set
+	AllowStderr     bool   // a program that writes to stderr doesn't fail
+
+	// Progress is told about each stage of judging, if set
+	Progress func(stage string, test, tests int)
+	// Context stops judging and kills the program when it is done, if set
+	Context context.Context
+}
+
+func (o Options) context() context.Context {
+	if o.Context == nil {
+		return context.Background()
+	}
+	return o.Context
+}
+
+func OptionsFor(problem models.Problem) Options {
+	return Options{
+		TimeLimit:       problem.TimeLimit,
+		MemoryLimit:     problem.MemoryLimit,
+		CheckerPath:     problem.CheckerPath,
+		CheckerLanguage: problem.CheckerLanguage,
+		CheckerProtocol: problem.CheckerProtocol,
+		Harnesses:       problem.Harnesses,
+		InputFile:       problem.InputFile,
+		OutputFile:      problem.OutputFile,
+	}
+}
+
+// env passes the limits and the input and output files to run.sh, which
+// falls back to its defaults for the ones that aren't set
+func (o Options) env() []string {
+	var env []string
+	if o.TimeLimit > 0 {
+		env = append(env, fmt.Sprintf("TIME_LIMIT=%.3f", float64(o.TimeLimit)/1000))
+	}
+	if o.MemoryLimit > 0 {
+		env = append(env, fmt.Sprintf("MEM_LIMIT=%dM", o.MemoryLimit))
+	}
+	if o.InputFile != "" {
+		env = append(env, "INPUT_FILE="+o.InputFile)
+	}
+	if o.OutputFile != "" {
+		env = append(env, "OUTPUT_FILE="+o.OutputFile)
+	}
+	if o.AllowStderr {
+		env = append(env, "ALLOW_STDERR=1")
+	}
+	return env
+}
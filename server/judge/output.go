@@ -0,0 +1,60 @@
+package judge
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/khayrultw/go-judge/models"
+)
+
+// JudgeOutputs checks the outputs submitted to an output-only problem, one
+// for each test, with the checker of the problem. Unlike JudgeWithOptions
+// it goes through every test, and returns the verdict on each of them.
+// outputs maps test numbers to outputs, a missing one fails its test.
+func JudgeOutputs(outputs map[int]string, testCaseFilePath string, opts Options) (models.Result, []models.TestResult) {
+	content, err := os.ReadFile(testCaseFilePath)
+	if err != nil {
+		return models.Result{Status: "ERROR", Message: "Test Case File Error"}, nil
+	}
+	testCases, err := ParseTestCases(string(content))
+	if err != nil {
+		return models.Result{Status: "FAIL", Message: err.Error()}, nil
+	}
+
+	var results []models.TestResult
+	accepted := 0
+	for _, tc := range testCases {
+		number := tc.Number
+		opts.progress(StageRunning, number, len(testCases))
+		output, ok := outputs[number]
+		if !ok {
+			results = append(results, models.TestResult{Test: number, Verdict: VerdictWrongAnswer, Message: "No output submitted"})
+			continue
+		}
+
+		inputFile, err := GetTestCaseFile(tc.Input)
+		if err != nil {
+			return models.Result{Status: "ERROR", Message: "Failed to create input file"}, nil
+		}
+		inputFilePath, _ := filepath.Abs(inputFile.Name())
+		ok, comment, err := opts.checkOutput(inputFilePath, output, tc.Output)
+		os.Remove(inputFile.Name())
+		if err != nil {
+			return models.Result{Status: "ERROR", Message: err.Error()}, nil
+		}
+
+		result := models.TestResult{Test: number, Verdict: VerdictWrongAnswer, Message: comment}
+		if ok {
+			result.Verdict = VerdictAccepted
+			accepted++
+		}
+		results = append(results, result)
+	}
+
+	if accepted == len(testCases) {
+		return models.Result{Status: "PASS", Message: ""}, results
+	}
+	message := fmt.Sprintf("Accepted on %d of %d tests", accepted, len(testCases))
+	return models.Result{Status: "FAIL", Message: message}, results
+}
@@ -0,0 +1,48 @@
+package judge
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/khayrultw/go-judge/utils"
+)
+
+// The stages of judging a submission
+const (
+	StageQueued    = "queued"
+	StageCompiling = "compiling"
+	StageRunning   = "running"
+	StageDone      = "done"
+)
+
+// Progress is an event on the topic of a submission. Position is set while
+// queued, Test and Tests while running, Status and Message once done.
+type Progress struct {
+	SubmissionId uint   `json:"submission_id"`
+	Stage        string `json:"stage"`
+	Test         int    `json:"test,omitempty"`
+	Tests        int    `json:"tests,omitempty"`
+	Position     int    `json:"position,omitempty"` // in the queue
+	Status       string `json:"status,omitempty"`
+	Message      string `json:"message,omitempty"`
+}
+
+// SubmissionTopic is the broadcaster topic of the progress of a submission
+func SubmissionTopic(submissionId uint) string {
+	return fmt.Sprintf("submission_%d", submissionId)
+}
+
+// PublishProgress sends the event to the topic of its submission
+func PublishProgress(progress Progress) {
+	content, err := json.Marshal(progress)
+	if err != nil {
+		return
+	}
+	utils.GetBroadcaster().Publish(SubmissionTopic(progress.SubmissionId), string(content))
+}
+
+func (o Options) progress(stage string, test, tests int) {
+	if o.Progress != nil {
+		o.Progress(stage, test, tests)
+	}
+}
@@ -0,0 +1,451 @@
+package judge
+
+import (
+	"cmp"
+	"context"
+	"log"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/khayrultw/go-judge/models"
+	"gorm.io/gorm"
+)
+
+// Priority is the class of a job. Lower classes are judged first.
+type Priority int
+
+const (
+	PriorityContest  Priority = iota // submissions to a running contest
+	PriorityCustom                   // code run on custom input, see RunCustom
+	PriorityPractice                 // submissions outside of a running contest
+	PriorityRejudge
+)
+
+// Job is a submission waiting to be judged, or a custom invocation with Run
+// set and only the UserId of Submission
+type Job struct {
+	Submission models.Submission
+	Problem    models.Problem
+	Priority   Priority
+	Run        func(ctx context.Context)
+
+	// done when the job is cancelled while it is judged
+	ctx    context.Context
+	cancel context.CancelFunc
+	// the remote worker judging the job and until when, see Lease
+	worker   string
+	deadline time.Time
+}
+
+// Queue holds the jobs waiting for a judge worker, and the submissions
+// being judged. Jobs are judged by priority, and within a priority users
+// take turns, so that many submissions of one user don't hold up the
+// others.
+type Queue struct {
+	mu      sync.Mutex
+	jobs    []*Job // in the order they came
+	running map[uint]*Job
+	// when each user last had a job handed out, counting hand-outs
+	served map[uint]uint64
+	tick   uint64
+	// how many workers run in this process, which custom invocations need
+	local int
+	// the order of jobs and the positions of submissions, computed once
+	// after each change, see order
+	ordered   []*Job
+	positions map[uint]int
+	// the positions last published, so that only changes are sent
+	published map[uint]int
+	// closed and replaced whenever a job is pushed, to wake the workers
+	wake chan struct{}
+}
+
+var (
+	queueOnce sync.Once
+	queue     *Queue
+)
+
+func GetQueue() *Queue {
+	queueOnce.Do(func() {
+		queue = newQueue()
+	})
+	return queue
+}
+
+func newQueue() *Queue {
+	return &Queue{
+		wake:      make(chan struct{}),
+		running:   make(map[uint]*Job),
+		served:    make(map[uint]uint64),
+		published: make(map[uint]int),
+	}
+}
+
+func (q *Queue) Push(job *Job) {
+	q.mu.Lock()
+	q.jobs = append(q.jobs, job)
+	q.ordered = nil
+	close(q.wake)
+	q.wake = make(chan struct{})
+	q.mu.Unlock()
+	q.publishPositions()
+}
+
+// Next takes the first job, waiting for one until ctx is done. The job is
+// running until it is passed to Finish.
+func (q *Queue) Next(ctx context.Context) (*Job, error) {
+	return q.NextMatching(ctx, func(*Job) bool { return true })
+}
+
+// NextMatching is Next for the first job accept takes
+func (q *Queue) NextMatching(ctx context.Context, accept func(*Job) bool) (*Job, error) {
+	for {
+		q.mu.Lock()
+		for _, job := range q.order() {
+			if !accept(job) {
+				continue
+			}
+			q.jobs = slices.DeleteFunc(q.jobs, func(j *Job) bool { return j == job })
+			q.tick++
+			q.served[job.Submission.UserId] = q.tick
+			q.ordered = nil
+			job.ctx, job.cancel = context.WithCancel(context.Background())
+			if job.Run == nil {
+				q.running[job.Submission.Id] = job
+			}
+			q.mu.Unlock()
+			q.publishPositions()
+			return job, nil
+		}
+		wake := q.wake
+		q.mu.Unlock()
+
+		select {
+		case <-wake:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// order is the order the queued jobs would be handed out in: by priority,
+// then to the user served longest ago, then in the order they came. Within
+// a priority the users take turns in the order they were served. It is
+// computed once after the queue changed, with the positions.
+func (q *Queue) order() []*Job {
+	if q.ordered != nil {
+		return q.ordered
+	}
+	served := maps.Clone(q.served)
+	tick := q.tick
+	jobs := slices.Clone(q.jobs)
+	slices.SortStableFunc(jobs, func(a, b *Job) int { return cmp.Compare(a.Priority, b.Priority) })
+
+	q.ordered = make([]*Job, 0, len(jobs))
+	for len(jobs) > 0 {
+		class := jobs
+		if end := slices.IndexFunc(jobs, func(job *Job) bool { return job.Priority != jobs[0].Priority }); end >= 0 {
+			class = jobs[:end]
+		}
+		jobs = jobs[len(class):]
+
+		// the jobs of each user, in the order they came
+		var users []uint
+		byUser := make(map[uint][]*Job)
+		for _, job := range class {
+			userId := job.Submission.UserId
+			if _, ok := byUser[userId]; !ok {
+				users = append(users, userId)
+			}
+			byUser[userId] = append(byUser[userId], job)
+		}
+		slices.SortStableFunc(users, func(a, b uint) int { return cmp.Compare(served[a], served[b]) })
+		for len(users) > 0 {
+			waiting := users[:0]
+			for _, userId := range users {
+				q.ordered = append(q.ordered, byUser[userId][0])
+				byUser[userId] = byUser[userId][1:]
+				tick++
+				served[userId] = tick
+				if len(byUser[userId]) > 0 {
+					waiting = append(waiting, userId)
+				}
+			}
+			users = waiting
+		}
+	}
+
+	q.positions = make(map[uint]int, len(q.ordered))
+	for i, job := range q.ordered {
+		if job.Run == nil {
+			q.positions[job.Submission.Id] = i + 1
+		}
+	}
+	return q.ordered
+}
+
+// Finish tells the queue a job it handed out is judged
+func (q *Queue) Finish(job *Job) {
+	q.mu.Lock()
+	if job.Run == nil && q.running[job.Submission.Id] == job {
+		delete(q.running, job.Submission.Id)
+	}
+	q.mu.Unlock()
+	job.cancel()
+}
+
+// lease gives a running job to a remote worker until the deadline
+func (q *Queue) lease(job *Job, worker string, deadline time.Time) {
+	q.mu.Lock()
+	job.worker, job.deadline = worker, deadline
+	q.mu.Unlock()
+}
+
+// renew extends the lease of a job if the worker still holds it. It
+// reports false once the job is cancelled or leased to another worker.
+func (q *Queue) renew(submissionId uint, worker string, deadline time.Time) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job, ok := q.running[submissionId]
+	if !ok || job.worker != worker {
+		return false
+	}
+	if job.ctx.Err() != nil {
+		delete(q.running, submissionId)
+		return false
+	}
+	job.deadline = deadline
+	return true
+}
+
+// take finishes a job leased to the worker and returns it, unless it was
+// cancelled or leased to another worker meanwhile
+func (q *Queue) take(submissionId uint, worker string) (*Job, bool) {
+	q.mu.Lock()
+	job, ok := q.running[submissionId]
+	if !ok || job.worker != worker {
+		q.mu.Unlock()
+		return nil, false
+	}
+	delete(q.running, submissionId)
+	q.mu.Unlock()
+	if job.ctx.Err() != nil {
+		return nil, false
+	}
+	job.cancel()
+	return job, true
+}
+
+// expire queues again the jobs whose worker stopped renewing its lease,
+// and forgets the cancelled ones
+func (q *Queue) expire(now time.Time) {
+	q.mu.Lock()
+	var expired []*Job
+	for id, job := range q.running {
+		if job.worker == "" || now.Before(job.deadline) {
+			continue
+		}
+		delete(q.running, id)
+		if job.ctx.Err() == nil {
+			job.cancel()
+			expired = append(expired, &Job{Submission: job.Submission, Problem: job.Problem, Priority: job.Priority})
+		}
+	}
+	if len(expired) > 0 {
+		q.jobs = append(expired, q.jobs...)
+		q.ordered = nil
+		close(q.wake)
+		q.wake = make(chan struct{})
+	}
+	q.mu.Unlock()
+	for _, job := range expired {
+		log.Printf("The lease of submission %d expired, queued it again", job.Submission.Id)
+	}
+	if len(expired) > 0 {
+		q.publishPositions()
+	}
+}
+
+// Remove takes a submission out of the queue, if it is still waiting
+func (q *Queue) Remove(submissionId uint) bool {
+	q.mu.Lock()
+	for i, job := range q.jobs {
+		if job.Run == nil && job.Submission.Id == submissionId {
+			q.jobs = append(q.jobs[:i], q.jobs[i+1:]...)
+			q.ordered = nil
+			q.mu.Unlock()
+			q.publishPositions()
+			return true
+		}
+	}
+	q.mu.Unlock()
+	return false
+}
+
+// Stop cancels the judging of a submission, if it is being judged
+func (q *Queue) Stop(submissionId uint) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job, ok := q.running[submissionId]
+	if ok {
+		job.cancel()
+	}
+	return ok
+}
+
+// Position is where the submission is in the queue, 1 for the next one to
+// be judged, or 0 if it is not waiting
+func (q *Queue) Position(submissionId uint) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.order()
+	return q.positions[submissionId]
+}
+
+// Len is how many jobs wait in the queue
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.jobs)
+}
+
+// publishPositions tells the waiting submissions whose position changed
+// where they are now
+func (q *Queue) publishPositions() {
+	q.mu.Lock()
+	q.order()
+	var events []Progress
+	for id, position := range q.positions {
+		if q.published[id] != position {
+			q.published[id] = position
+			events = append(events, Progress{SubmissionId: id, Stage: StageQueued, Position: position})
+		}
+	}
+	for id := range q.published {
+		if _, ok := q.positions[id]; !ok {
+			delete(q.published, id)
+		}
+	}
+	q.mu.Unlock()
+	slices.SortFunc(events, func(a, b Progress) int { return cmp.Compare(a.Position, b.Position) })
+	for _, event := range events {
+		PublishProgress(event)
+	}
+}
+
+// Enqueue records when the submission was queued, unless it already was,
+// and queues it with the priority of a contest submission while its contest
+// runs and of practice otherwise
+func Enqueue(db *gorm.DB, submission models.Submission, problem models.Problem) {
+	priority := PriorityPractice
+	var contest models.Contest
+	if submission.ContestId != 0 && db.First(&contest, submission.ContestId).Error == nil {
+		now := time.Now()
+		end := contest.StartTime.Add(time.Duration(contest.Duration) * time.Minute)
+		if !now.Before(contest.StartTime.Time) && now.Before(end) {
+			priority = PriorityContest
+		}
+	}
+	enqueue(db, submission, problem, priority)
+}
+
+func enqueue(db *gorm.DB, submission models.Submission, problem models.Problem, priority Priority) {
+	if submission.QueuedAt == nil {
+		now := models.GetCurrentTime()
+		submission.QueuedAt = &now
+		db.Model(&submission).Update("queued_at", now)
+	}
+	GetQueue().Push(&Job{Submission: submission, Problem: problem, Priority: priority})
+}
+
+// RunCustom runs fn for the user on a worker of this process, with the
+// priority of custom invocations, or right away if this process has no
+// workers. It gives up waiting for a worker once ctx is done.
+func RunCustom(ctx context.Context, userId uint, fn func()) error {
+	q := GetQueue()
+	q.mu.Lock()
+	local := q.local
+	q.mu.Unlock()
+	if local == 0 {
+		fn()
+		return nil
+	}
+
+	done := make(chan struct{})
+	q.Push(&Job{
+		Submission: models.Submission{UserId: userId},
+		Priority:   PriorityCustom,
+		Run: func(context.Context) {
+			defer close(done)
+			if ctx.Err() == nil {
+				fn()
+			}
+		},
+	})
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Background runs fn for the user on a worker of this process, with the
+// priority, or right away in a goroutine of its own if this process has no
+// workers
+func Background(userId uint, priority Priority, fn func(ctx context.Context)) {
+	q := GetQueue()
+	q.mu.Lock()
+	local := q.local
+	q.mu.Unlock()
+	if local == 0 {
+		go fn(context.Background())
+		return
+	}
+	q.Push(&Job{Submission: models.Submission{UserId: userId}, Priority: priority, Run: fn})
+}
+
+// StartWorkers queues the submissions left pending by the last run and
+// starts the workers that judge the queue in this process. Remote workers
+// can lease jobs from the same queue.
+func StartWorkers(db *gorm.DB, workers int) {
+	var pending []models.Submission
+	if err := db.Where("status = ? OR automatic_status = ?", "pending", "pending").Order("id ASC").Find(&pending).Error; err != nil {
+		log.Printf("Failed to queue pending submissions: %v", err)
+	}
+	problems := make(map[uint]models.Problem)
+	for _, submission := range pending {
+		problem, ok := problems[submission.ProblemId]
+		if !ok {
+			if err := db.First(&problem, submission.ProblemId).Error; err != nil {
+				log.Printf("Failed to queue submission %d: %v", submission.Id, err)
+				continue
+			}
+			problems[submission.ProblemId] = problem
+		}
+		Enqueue(db, submission, problem)
+	}
+
+	GetQueue().mu.Lock()
+	GetQueue().local = workers
+	GetQueue().mu.Unlock()
+	go expireLeases()
+	for i := 0; i < workers; i++ {
+		go func() {
+			for {
+				job, err := GetQueue().Next(context.Background())
+				if err != nil {
+					return
+				}
+				if job.Run != nil {
+					job.Run(job.ctx)
+				} else {
+					RunTestContext(job.ctx, db, job.Submission, job.Problem)
+				}
+				GetQueue().Finish(job)
+			}
+		}()
+	}
+}
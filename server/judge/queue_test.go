@@ -0,0 +1,176 @@
+package judge
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/khayrultw/go-judge/models"
+	"github.com/khayrultw/go-judge/utils"
+)
+
+type queued struct {
+	id       uint
+	user     uint
+	priority Priority
+}
+
+func queueOf(jobs []queued, served map[uint]uint64) *Queue {
+	q := newQueue()
+	for _, job := range jobs {
+		q.jobs = append(q.jobs, &Job{Submission: models.Submission{Id: job.id, UserId: job.user}, Priority: job.priority})
+	}
+	for user, tick := range served {
+		q.served[user] = tick
+		q.tick = max(q.tick, tick)
+	}
+	return q
+}
+
+func submissionIds(jobs []*Job) []uint {
+	ids := make([]uint, 0, len(jobs))
+	for _, job := range jobs {
+		ids = append(ids, job.Submission.Id)
+	}
+	return ids
+}
+
+func TestQueueOrder(t *testing.T) {
+	tests := []struct {
+		name   string
+		jobs   []queued
+		served map[uint]uint64
+		want   []uint
+	}{
+		{
+			name: "empty",
+			want: []uint{},
+		},
+		{
+			name: "one user in the order they came",
+			jobs: []queued{{1, 1, PriorityPractice}, {2, 1, PriorityPractice}, {3, 1, PriorityPractice}},
+			want: []uint{1, 2, 3},
+		},
+		{
+			name: "priority classes",
+			jobs: []queued{
+				{1, 1, PriorityRejudge},
+				{2, 2, PriorityPractice},
+				{3, 3, PriorityCustom},
+				{4, 4, PriorityContest},
+			},
+			want: []uint{4, 3, 2, 1},
+		},
+		{
+			name: "users take turns",
+			jobs: []queued{
+				{1, 1, PriorityContest},
+				{2, 1, PriorityContest},
+				{3, 1, PriorityContest},
+				{4, 2, PriorityContest},
+				{5, 2, PriorityContest},
+				{6, 3, PriorityContest},
+			},
+			want: []uint{1, 4, 6, 2, 5, 3},
+		},
+		{
+			name:   "user served longest ago first",
+			jobs:   []queued{{1, 1, PriorityPractice}, {2, 2, PriorityPractice}, {3, 3, PriorityPractice}},
+			served: map[uint]uint64{1: 7, 2: 5, 3: 6},
+			want:   []uint{2, 3, 1},
+		},
+		{
+			name:   "users never served first, in the order they came",
+			jobs:   []queued{{1, 1, PriorityPractice}, {2, 3, PriorityPractice}, {3, 2, PriorityPractice}},
+			served: map[uint]uint64{1: 1},
+			want:   []uint{2, 3, 1},
+		},
+		{
+			name: "turns carry over to the next class",
+			jobs: []queued{
+				{1, 1, PriorityPractice},
+				{2, 2, PriorityPractice},
+				{3, 1, PriorityContest},
+			},
+			want: []uint{3, 2, 1},
+		},
+		{
+			name: "classes before turns",
+			jobs: []queued{
+				{1, 1, PriorityContest},
+				{2, 1, PriorityContest},
+				{3, 2, PriorityRejudge},
+				{4, 1, PriorityContest},
+			},
+			want: []uint{1, 2, 4, 3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := queueOf(tt.jobs, tt.served)
+			if got := submissionIds(q.order()); !slices.Equal(got, tt.want) {
+				t.Errorf("order() = %v, want %v", got, tt.want)
+			}
+			for i, id := range tt.want {
+				if got := q.Position(id); got != i+1 {
+					t.Errorf("Position(%d) = %d, want %d", id, got, i+1)
+				}
+			}
+		})
+	}
+}
+
+func TestQueueNextUpdatesOrder(t *testing.T) {
+	q := queueOf([]queued{
+		{1, 1, PriorityPractice},
+		{2, 1, PriorityPractice},
+		{3, 2, PriorityPractice},
+	}, nil)
+	if got := submissionIds(q.order()); !slices.Equal(got, []uint{1, 3, 2}) {
+		t.Fatalf("order() = %v, want [1 3 2]", got)
+	}
+
+	job, err := q.Next(context.Background())
+	if err != nil || job.Submission.Id != 1 {
+		t.Fatalf("Next() = %v, %v, want submission 1", job, err)
+	}
+	q.Push(&Job{Submission: models.Submission{Id: 4, UserId: 3}, Priority: PriorityPractice})
+	q.Push(&Job{Submission: models.Submission{Id: 5, UserId: 1}, Priority: PriorityContest})
+	if got := submissionIds(q.order()); !slices.Equal(got, []uint{5, 3, 4, 2}) {
+		t.Errorf("order() = %v, want [5 3 4 2]", got)
+	}
+	if !q.Remove(3) {
+		t.Fatal("Remove(3) = false")
+	}
+	if got, want := q.Position(2), 3; got != want {
+		t.Errorf("Position(2) = %d, want %d", got, want)
+	}
+	if got := q.Position(3); got != 0 {
+		t.Errorf("Position(3) = %d after Remove, want 0", got)
+	}
+}
+
+func TestQueuePublishesChangedPositions(t *testing.T) {
+	q := queueOf([]queued{{101, 1, PriorityPractice}, {102, 2, PriorityPractice}}, nil)
+	events := make(map[uint]utils.SSEClient)
+	for _, id := range []uint{101, 102, 103} {
+		events[id] = utils.GetBroadcaster().Subscribe(SubmissionTopic(id))
+		defer utils.GetBroadcaster().Unsubscribe(SubmissionTopic(id), events[id])
+	}
+	q.publishPositions()
+	for _, id := range []uint{101, 102} {
+		if len(events[id]) != 1 {
+			t.Fatalf("submission %d got %d events, want 1", id, len(events[id]))
+		}
+		<-events[id]
+	}
+
+	// a job behind the others moves nobody
+	q.Push(&Job{Submission: models.Submission{Id: 103, UserId: 3}, Priority: PriorityRejudge})
+	want := map[uint]int{101: 0, 102: 0, 103: 1}
+	for id, n := range want {
+		if len(events[id]) != n {
+			t.Errorf("submission %d got %d events, want %d", id, len(events[id]), n)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package judge
+
+import (
+	"log"
+
+	"github.com/khayrultw/go-judge/models"
+	"gorm.io/gorm"
+)
+
+// Rejudge moves the verdicts of the submissions to their history, marks
+// them pending and queues them to be judged again against the current
+// revision of their problems. An overridden submission keeps the verdict an
+// admin gave it and only its automatic verdict becomes pending.
+func Rejudge(db *gorm.DB, submissions []models.Submission, by uint) error {
+	if len(submissions) == 0 {
+		return nil
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for _, submission := range submissions {
+			status, pending := submission.Status, "status"
+			if submission.AutomaticStatus != "" {
+				status, pending = submission.AutomaticStatus, "automatic_status"
+			}
+			history := models.SubmissionHistory{
+				SubmissionId:    submission.Id,
+				Status:          status,
+				Message:         submission.Message,
+				ProblemRevision: submission.ProblemRevision,
+				RejudgedBy:      by,
+			}
+			if err := tx.Create(&history).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("submission_id = ?", submission.Id).Delete(&models.TestResult{}).Error; err != nil {
+				return err
+			}
+			err := tx.Model(&submission).Updates(map[string]interface{}{
+				pending:              "pending",
+				"message":            "",
+				"queued_at":          nil,
+				"compile_started_at": nil,
+				"run_started_at":     nil,
+				"finished_at":        nil,
+			}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	problems := make(map[uint]models.Problem)
+	for _, submission := range submissions {
+		problem, ok := problems[submission.ProblemId]
+		if !ok {
+			if err := db.First(&problem, submission.ProblemId).Error; err != nil {
+				log.Printf("Failed to rejudge submission %d: %v", submission.Id, err)
+				continue
+			}
+			problems[submission.ProblemId] = problem
+		}
+		if submission.AutomaticStatus != "" {
+			submission.AutomaticStatus = "pending"
+		} else {
+			submission.Status = "pending"
+		}
+		submission.Message = ""
+		submission.QueuedAt = nil
+		enqueue(db, submission, problem, PriorityRejudge)
+	}
+	return nil
+}
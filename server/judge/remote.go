@@ -0,0 +1,305 @@
+package judge
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/khayrultw/go-judge/models"
+	"gorm.io/gorm"
+)
+
+// Remote workers judge on other machines. A worker registers its
+// languages, leases jobs from the queue with a long poll, fetches the files
+// of a job by their content hash, renews the lease with a heartbeat and
+// each progress event and reports the verdict. A job whose lease runs out
+// goes back to the queue, and a worker that stops calling is forgotten.
+
+// LeaseTimeout is how long a worker holds a job without renewing the lease
+const LeaseTimeout = 2 * time.Minute
+
+// HeartbeatInterval is how often a worker renews the lease of a job it is
+// judging, whether or not the job makes progress
+const HeartbeatInterval = LeaseTimeout / 4
+
+// RemoteWorker is a registered worker
+type RemoteWorker struct {
+	Id        string    `json:"id"`
+	Name      string    `json:"name"`
+	Languages []string  `json:"languages"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// RemoteFile is a file of a job, fetched by its sha256 hash
+type RemoteFile struct {
+	Name string `json:"name"`
+	Hash string `json:"hash"`
+}
+
+// RemoteJob is a leased submission with what a worker needs to judge it.
+// Data has the tests as testcase.txt, the checker and the headers next to
+// it.
+type RemoteJob struct {
+	SubmissionId    uint                    `json:"submission_id"`
+	Language        string                  `json:"language"`
+	SourceCode      string                  `json:"source_code,omitempty"`
+	Files           []models.SubmissionFile `json:"files,omitempty"`
+	Outputs         map[int]string          `json:"outputs,omitempty"` // of output-only submissions
+	OutputOnly      bool                    `json:"output_only,omitempty"`
+	TimeLimit       uint                    `json:"time_limit"`
+	MemoryLimit     uint                    `json:"memory_limit"`
+	Checker         string                  `json:"checker,omitempty"` // name in Data
+	CheckerLanguage string                  `json:"checker_language,omitempty"`
+	CheckerProtocol string                  `json:"checker_protocol,omitempty"`
+	Harnesses       []models.Harness        `json:"harnesses,omitempty"`
+	InputFile       string                  `json:"input_file,omitempty"`
+	OutputFile      string                  `json:"output_file,omitempty"`
+	Data            []RemoteFile            `json:"data"`
+}
+
+// RemoteTestFile is the name of the tests among the data of a job
+const RemoteTestFile = "testcase.txt"
+
+// RemoteProgress is a progress event of a worker
+type RemoteProgress struct {
+	WorkerId     string `json:"worker_id"`
+	SubmissionId uint   `json:"submission_id"`
+	Stage        string `json:"stage"`
+	Test         int    `json:"test,omitempty"`
+	Tests        int    `json:"tests,omitempty"`
+}
+
+// RemoteResult is the verdict a worker reports
+type RemoteResult struct {
+	WorkerId     string              `json:"worker_id"`
+	SubmissionId uint                `json:"submission_id"`
+	Status       string              `json:"status"`
+	Message      string              `json:"message"`
+	Tests        []models.TestResult `json:"tests,omitempty"`
+}
+
+var (
+	workersMu sync.Mutex
+	workers   = make(map[string]*RemoteWorker)
+)
+
+// RegisterWorker adds a worker that judges the languages, and output-only
+// submissions
+func RegisterWorker(name string, languages []string) RemoteWorker {
+	id := make([]byte, 16)
+	rand.Read(id)
+	worker := &RemoteWorker{
+		Id:        hex.EncodeToString(id),
+		Name:      name,
+		Languages: append(slices.Clone(languages), models.LanguageOutput),
+		LastSeen:  time.Now(),
+	}
+	workersMu.Lock()
+	workers[worker.Id] = worker
+	workersMu.Unlock()
+	return *worker
+}
+
+// Workers lists the registered workers
+func Workers() []RemoteWorker {
+	workersMu.Lock()
+	defer workersMu.Unlock()
+	list := make([]RemoteWorker, 0, len(workers))
+	for _, worker := range workers {
+		list = append(list, *worker)
+	}
+	slices.SortFunc(list, func(a, b RemoteWorker) int { return a.LastSeen.Compare(b.LastSeen) })
+	return list
+}
+
+// seen marks the worker as alive and returns it
+func seen(workerId string) (*RemoteWorker, error) {
+	workersMu.Lock()
+	defer workersMu.Unlock()
+	worker, ok := workers[workerId]
+	if !ok {
+		return nil, ErrUnknownWorker
+	}
+	worker.LastSeen = time.Now()
+	return worker, nil
+}
+
+// ErrUnknownWorker tells a worker to register again, after the server
+// restarted
+var ErrUnknownWorker = fmt.Errorf("Unknown worker")
+
+// Lease waits until ctx is done for a job in one of the languages of the
+// worker, or returns nil
+func Lease(ctx context.Context, db *gorm.DB, workerId string) (*RemoteJob, error) {
+	worker, err := seen(workerId)
+	if err != nil {
+		return nil, err
+	}
+	job, err := GetQueue().NextMatching(ctx, func(job *Job) bool {
+		return job.Run == nil && slices.Contains(worker.Languages, job.Submission.Language)
+	})
+	if err != nil {
+		return nil, nil
+	}
+	GetQueue().lease(job, workerId, time.Now().Add(LeaseTimeout))
+
+	remote, err := remoteJob(job)
+	if err != nil {
+		GetQueue().Finish(job)
+		recordVerdict(db, job.Submission, job.Problem, models.Result{Status: "ERROR", Message: err.Error()}, nil)
+		return nil, nil
+	}
+	return remote, nil
+}
+
+func remoteJob(job *Job) (*RemoteJob, error) {
+	submission, problem := job.Submission, job.Problem
+	remote := &RemoteJob{
+		SubmissionId:    submission.Id,
+		Language:        submission.Language,
+		SourceCode:      submission.SourceCode,
+		Files:           submission.Files,
+		OutputOnly:      problem.Type == models.ProblemTypeOutputOnly,
+		TimeLimit:       problem.TimeLimit,
+		MemoryLimit:     problem.MemoryLimit,
+		CheckerLanguage: problem.CheckerLanguage,
+		CheckerProtocol: problem.CheckerProtocol,
+		Harnesses:       problem.Harnesses,
+		InputFile:       problem.InputFile,
+		OutputFile:      problem.OutputFile,
+	}
+	if remote.OutputOnly {
+		remote.Outputs = submittedOutputs(submission.Id)
+	}
+
+	hash, err := shareFile(problem.TestCasePath)
+	if err != nil {
+		return nil, err
+	}
+	remote.Data = append(remote.Data, RemoteFile{Name: RemoteTestFile, Hash: hash})
+
+	if problem.CheckerPath != "" {
+		remote.Checker = filepath.Base(problem.CheckerPath)
+		headers, _ := filepath.Glob(filepath.Join(filepath.Dir(problem.CheckerPath), "*.h"))
+		for _, path := range append([]string{problem.CheckerPath}, headers...) {
+			hash, err := shareFile(path)
+			if err != nil {
+				return nil, err
+			}
+			remote.Data = append(remote.Data, RemoteFile{Name: filepath.Base(path), Hash: hash})
+		}
+	}
+	return remote, nil
+}
+
+// Heartbeat renews the lease of a job. It reports false once the job is
+// cancelled, or no longer leased to the worker, so that the worker stops
+// judging it.
+func Heartbeat(workerId string, submissionId uint) (bool, error) {
+	if _, err := seen(workerId); err != nil {
+		return false, err
+	}
+	return GetQueue().renew(submissionId, workerId, time.Now().Add(LeaseTimeout)), nil
+}
+
+// ReportProgress records the progress of a leased job and renews the lease
+// like Heartbeat
+func ReportProgress(db *gorm.DB, progress RemoteProgress) (bool, error) {
+	leased, err := Heartbeat(progress.WorkerId, progress.SubmissionId)
+	if err != nil || !leased {
+		return false, err
+	}
+	recordProgress(db, progress.SubmissionId, progress.Stage, progress.Test, progress.Tests)
+	return true, nil
+}
+
+// ReportResult records the verdict of a leased job. The verdict of a job
+// that was cancelled or leased again meanwhile is dropped.
+func ReportResult(db *gorm.DB, result RemoteResult) error {
+	if _, err := seen(result.WorkerId); err != nil {
+		return err
+	}
+	job, ok := GetQueue().take(result.SubmissionId, result.WorkerId)
+	if !ok {
+		return nil
+	}
+	recordVerdict(db, job.Submission, job.Problem, models.Result{Status: result.Status, Message: result.Message}, result.Tests)
+	return nil
+}
+
+type sharedFile struct {
+	Path    string
+	ModTime time.Time
+	Hash    string
+}
+
+var (
+	sharedMu     sync.Mutex
+	sharedByPath = make(map[string]sharedFile)
+	sharedByHash = make(map[string]string)
+)
+
+// shareFile hashes a file, once until it changes, and lets workers fetch
+// it by the hash
+func shareFile(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("Failed to read %s", filepath.Base(path))
+	}
+	sharedMu.Lock()
+	defer sharedMu.Unlock()
+	if shared, ok := sharedByPath[path]; ok && shared.ModTime.Equal(info.ModTime()) {
+		return shared.Hash, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("Failed to read %s", filepath.Base(path))
+	}
+	defer file.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", fmt.Errorf("Failed to read %s", filepath.Base(path))
+	}
+	hash := hex.EncodeToString(h.Sum(nil))
+	sharedByPath[path] = sharedFile{Path: path, ModTime: info.ModTime(), Hash: hash}
+	sharedByHash[hash] = path
+	return hash, nil
+}
+
+// SharedFile is the path of a file handed out to workers, by its hash
+func SharedFile(hash string) (string, bool) {
+	sharedMu.Lock()
+	defer sharedMu.Unlock()
+	path, ok := sharedByHash[hash]
+	return path, ok
+}
+
+// expireLeases queues again the jobs of workers that went away, and
+// forgets the workers
+func expireLeases() {
+	for now := range time.Tick(LeaseTimeout / 4) {
+		GetQueue().expire(now)
+		pruneWorkers(now.Add(-LeaseTimeout))
+	}
+}
+
+// pruneWorkers forgets the workers not seen since before. A worker that
+// comes back registers again.
+func pruneWorkers(before time.Time) {
+	workersMu.Lock()
+	defer workersMu.Unlock()
+	for id, worker := range workers {
+		if worker.LastSeen.Before(before) {
+			delete(workers, id)
+		}
+	}
+}
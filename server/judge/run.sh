@@ -3,27 +3,46 @@
 COMPILED_CODE="$1"
 INPUT_STRING="$2"  
 LANG="$3"
-MEM_LIMIT=512M          # Memory limit
-TIME_LIMIT=2.5            # Time limit in seconds
+shift 3                 # anything left is passed to the program as arguments
+MEM_LIMIT=${MEM_LIMIT:-512M}          # Memory limit
+TIME_LIMIT=${TIME_LIMIT:-2.5}            # Time limit in seconds
+INPUT_FILE=${INPUT_FILE:-}               # read by the program instead of stdin
+OUTPUT_FILE=${OUTPUT_FILE:-}             # written by the program instead of stdout
 ERROR_OUTPUT=$(mktemp /tmp/error_output-XXXXXX)
+WORK_DIR=$(mktemp -d /tmp/work-XXXXXX)   # the working directory of the program
+SCOPE_UNIT=${SCOPE_UNIT:-}               # names the scope, so that the judge can kill it
 
 case "$LANG" in
     cpp) RUN_CMD="$COMPILED_CODE" ;;
     py) RUN_CMD="python3 $COMPILED_CODE" ;;
     kt) RUN_CMD="$COMPILED_CODE" ;;
     js) RUN_CMD="v8 $COMPILED_CODE" ;;
+    java) RUN_CMD="java -jar $COMPILED_CODE" ;;
     *) RUN_CMD="$COMPILED_CODE" ;;
 esac
 
 cleanup() {
     [[ -f "$ERROR_OUTPUT" ]] && rm -f "$ERROR_OUTPUT"
+    [[ -d "$WORK_DIR" ]] && rm -rf "$WORK_DIR"
 }
 
 trap cleanup EXIT
 
+SCOPE_ARGS=()
+if [[ -n "$SCOPE_UNIT" ]]; then
+    SCOPE_ARGS=(--unit="$SCOPE_UNIT")
+fi
+
+STDIN="$INPUT_STRING"
+if [[ -n "$INPUT_FILE" ]]; then
+    cp "$INPUT_STRING" "$WORK_DIR/$INPUT_FILE"
+    STDIN=/dev/null
+fi
+
 actual_output=$(
-    systemd-run --quiet --user --scope -p MemoryMax=$MEM_LIMIT \
-    timeout $TIME_LIMIT $RUN_CMD < "$INPUT_STRING" 2>"$ERROR_OUTPUT"
+    cd "$WORK_DIR" && \
+    systemd-run --quiet --user --scope "${SCOPE_ARGS[@]}" -p MemoryMax=$MEM_LIMIT \
+    timeout $TIME_LIMIT $RUN_CMD "$@" < "$STDIN" 2>"$ERROR_OUTPUT"
 )
 exit_code=$?
 
@@ -43,9 +62,18 @@ if [[ $exit_code -ge 128 ]]; then
     fi
 fi
 
+if [[ $exit_code -ne 0 ]]; then
+    cat "$ERROR_OUTPUT" >&2
+    exit $exit_code
+fi
+
 if [[ -s "$ERROR_OUTPUT" ]]; then
     cat "$ERROR_OUTPUT" >&2
-    exit 1
+    [[ -z "$ALLOW_STDERR" ]] && exit 1
+fi
+
+if [[ -n "$OUTPUT_FILE" ]]; then
+    actual_output=$(cat "$WORK_DIR/$OUTPUT_FILE" 2>/dev/null)
 fi
 
 echo -e "$actual_output"
@@ -0,0 +1,186 @@
+package judge
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Languages are the languages the judge scripts support
+var Languages = []string{"cpp", "py", "kt", "js", "java"}
+
+// SelfCheck is the result of running one program of the self-test
+type SelfCheck struct {
+	Name     string `json:"name"`
+	Expected string `json:"expected"`
+	Status   string `json:"status"`
+	OK       bool   `json:"ok"`
+	Usage
+	Stderr string `json:"stderr,omitempty"`
+}
+
+// LanguageHealth is the self-test of a language and the version of its
+// compiler or interpreter
+type LanguageHealth struct {
+	Language string      `json:"language"`
+	Version  string      `json:"version"`
+	OK       bool        `json:"ok"`
+	Checks   []SelfCheck `json:"checks"`
+}
+
+type Health struct {
+	OK        bool             `json:"ok"`
+	CheckedAt time.Time        `json:"checked_at"`
+	Languages []LanguageHealth `json:"languages"`
+	Queued    int              `json:"queued"`
+	Workers   []RemoteWorker   `json:"workers"`
+}
+
+type selfTestProgram struct {
+	Name     string
+	Expected string
+	Source   string
+	Opts     Options
+	// also fine, for runtimes that fail on their own before the sandbox
+	// limit is reached
+	Alternative string
+}
+
+// the limits the time and memory limit programs run with
+var (
+	selfTestTLE = Options{TimeLimit: 1000}
+	selfTestMLE = Options{MemoryLimit: 128}
+)
+
+var selfTestPrograms = map[string][]selfTestProgram{
+	"cpp": {
+		{Name: "hello", Expected: RunOK, Source: "#include <cstdio>\nint main() { puts(\"hello\"); }\n"},
+		{Name: "tle", Expected: VerdictTimeLimit, Opts: selfTestTLE, Source: "int main() { volatile unsigned long x = 0; for (;;) x++; }\n"},
+		{Name: "mle", Expected: VerdictMemoryLimit, Opts: selfTestMLE, Source: "#include <vector>\nint main() {\n    std::vector<char *> blocks;\n    for (;;) {\n        char *block = new char[1 << 20];\n        for (int i = 0; i < (1 << 20); i += 4096) block[i] = 1;\n        blocks.push_back(block);\n    }\n}\n"},
+	},
+	"py": {
+		{Name: "hello", Expected: RunOK, Source: "print(\"hello\")\n"},
+		{Name: "tle", Expected: VerdictTimeLimit, Opts: selfTestTLE, Source: "while True:\n    pass\n"},
+		{Name: "mle", Expected: VerdictMemoryLimit, Opts: selfTestMLE, Source: "blocks = []\nwhile True:\n    blocks.append(b\"x\" * (1 << 20))\n"},
+	},
+	"kt": {
+		{Name: "hello", Expected: RunOK, Source: "fun main() {\n    println(\"hello\")\n}\n"},
+		{Name: "tle", Expected: VerdictTimeLimit, Opts: selfTestTLE, Source: "fun main() {\n    var x = 0L\n    while (true) {\n        x++\n    }\n}\n"},
+		{Name: "mle", Expected: VerdictMemoryLimit, Opts: selfTestMLE, Source: "fun main() {\n    val blocks = ArrayList<ByteArray>()\n    while (true) {\n        val block = ByteArray(1 shl 20)\n        for (i in block.indices step 4096) block[i] = 1\n        blocks.add(block)\n    }\n}\n"},
+	},
+	"js": {
+		{Name: "hello", Expected: RunOK, Source: "console.log(\"hello\");\n"},
+		{Name: "tle", Expected: VerdictTimeLimit, Opts: selfTestTLE, Source: "for (;;) {}\n"},
+		{Name: "mle", Expected: VerdictMemoryLimit, Opts: selfTestMLE, Alternative: VerdictRuntimeError, Source: "const blocks = [];\nfor (;;) blocks.push(new Array(1 << 17).fill(1));\n"},
+	},
+	"java": {
+		{Name: "hello", Expected: RunOK, Source: "public class Main {\n    public static void main(String[] args) {\n        System.out.println(\"hello\");\n    }\n}\n"},
+		{Name: "tle", Expected: VerdictTimeLimit, Opts: selfTestTLE, Source: "public class Main {\n    public static void main(String[] args) {\n        long x = 0;\n        while (true) x++;\n    }\n}\n"},
+		{Name: "mle", Expected: VerdictMemoryLimit, Opts: selfTestMLE, Alternative: VerdictRuntimeError, Source: "import java.util.*;\n\npublic class Main {\n    public static void main(String[] args) {\n        List<long[]> blocks = new ArrayList<>();\n        while (true) blocks.add(new long[1 << 17]);\n    }\n}\n"},
+	},
+}
+
+// versionCommands print the version of the toolchain of each language,
+// the compiler or the interpreter run.sh runs it with
+var versionCommands = map[string][]string{
+	"cpp":  {"g++", "--version"},
+	"py":   {"python3", "--version"},
+	"kt":   {"kotlinc-native", "-version"},
+	"js":   {"v8", "-e", "print(version())"},
+	"java": {"javac", "-version"},
+}
+
+var (
+	healthMu   sync.Mutex
+	lastHealth *Health
+)
+
+// SelfTest compiles and runs a program that prints hello, one that runs
+// out of time and one that runs out of memory in each language, all of
+// Languages if none are given, the way submissions are run, and keeps the
+// report for LastHealth
+func SelfTest(languages []string) Health {
+	if len(languages) == 0 {
+		languages = Languages
+	}
+	health := Health{OK: true, CheckedAt: time.Now(), Languages: make([]LanguageHealth, len(languages))}
+	var wg sync.WaitGroup
+	for i, lang := range languages {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			health.Languages[i] = selfTestLanguage(lang)
+		}()
+	}
+	wg.Wait()
+	for _, lang := range health.Languages {
+		health.OK = health.OK && lang.OK
+	}
+
+	healthMu.Lock()
+	lastHealth = &health
+	healthMu.Unlock()
+	return health
+}
+
+// LastHealth is the report of the last self-test, with the current queue
+// and workers
+func LastHealth() (Health, bool) {
+	healthMu.Lock()
+	defer healthMu.Unlock()
+	if lastHealth == nil {
+		return Health{}, false
+	}
+	health := *lastHealth
+	health.Queued = GetQueue().Len()
+	health.Workers = Workers()
+	return health, true
+}
+
+func selfTestLanguage(lang string) LanguageHealth {
+	health := LanguageHealth{Language: lang, Version: toolchainVersion(lang), OK: true}
+	programs, ok := selfTestPrograms[lang]
+	if !ok {
+		health.OK = false
+		health.Checks = append(health.Checks, SelfCheck{Name: "hello", Expected: RunOK, Status: "Unsupported language"})
+		return health
+	}
+	for _, program := range programs {
+		run := CustomRun(program.Source, lang, "", program.Opts)
+		check := SelfCheck{
+			Name:     program.Name,
+			Expected: program.Expected,
+			Status:   run.Status,
+			OK:       run.Status == program.Expected || program.Alternative != "" && run.Status == program.Alternative,
+			Usage:    run.Usage,
+		}
+		if program.Expected == RunOK && strings.TrimSpace(run.Stdout) != "hello" {
+			check.OK = false
+		}
+		if !check.OK {
+			check.Stderr = run.Stderr
+		}
+		health.OK = health.OK && check.OK
+		health.Checks = append(health.Checks, check)
+	}
+	return health
+}
+
+// toolchainVersion is the first line the version command prints, or ""
+// if the toolchain is missing
+func toolchainVersion(lang string) string {
+	command, ok := versionCommands[lang]
+	if !ok {
+		return ""
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, command[0], command[1:]...).CombinedOutput()
+	if err != nil {
+		return ""
+	}
+	line, _, _ := strings.Cut(strings.TrimSpace(string(output)), "\n")
+	return line
+}
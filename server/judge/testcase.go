@@ -0,0 +1,48 @@
+package judge
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	TestCaseSeparator = "#TEST_CASE_SEP#"
+	InOutSeparator    = "#IN_OUT_SEP#"
+)
+
+type TestCase struct {
+	Number int
+	Input  string
+	Output string
+}
+
+// ParseTestCases splits the content of a testcase file into its tests.
+// Tests are numbered by their position in the file, starting from 1.
+func ParseTestCases(content string) ([]TestCase, error) {
+	var testCases []TestCase
+	for idx, tc := range strings.Split(content, TestCaseSeparator) {
+		tc = strings.TrimSpace(tc)
+		if tc == "" {
+			continue
+		}
+		parts := strings.Split(tc, InOutSeparator)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("Test case %d: Invalid format", idx+1)
+		}
+		testCases = append(testCases, TestCase{
+			Number: idx + 1,
+			Input:  strings.TrimSpace(parts[0]),
+			Output: strings.TrimSpace(parts[1]),
+		})
+	}
+	return testCases, nil
+}
+
+// FormatTestCases is the inverse of ParseTestCases
+func FormatTestCases(testCases []TestCase) string {
+	tests := make([]string, len(testCases))
+	for i, tc := range testCases {
+		tests[i] = tc.Input + "\n" + InOutSeparator + "\n" + tc.Output
+	}
+	return strings.Join(tests, "\n"+TestCaseSeparator+"\n")
+}
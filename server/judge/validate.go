@@ -0,0 +1,55 @@
+package judge
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+type ValidationReport struct {
+	Test    int    `json:"test"`
+	Message string `json:"message"`
+}
+
+// ValidateTests runs the validator program over the input of every test in
+// testcaseText. The validator can include the headers in includeDir, the
+// directory of the problem files. A test is invalid when the validator
+// exits with a non-zero code; whatever it printed to stderr is reported as
+// the reason.
+func ValidateTests(validatorSource, lang, testcaseText, includeDir string) ([]ValidationReport, error) {
+	testCases, err := ParseTestCases(testcaseText)
+	if err != nil {
+		return nil, err
+	}
+
+	compiled, err := CompileCode(validatorSource, lang, includeDir)
+	if err != nil {
+		return nil, fmt.Errorf("Validator failed to compile: %s", compiled.Stderr)
+	}
+	defer os.Remove(compiled.FilePath)
+
+	reports := []ValidationReport{}
+	for _, tc := range testCases {
+		inputFile, err := GetTestCaseFile(tc.Input + "\n")
+		if err != nil {
+			return nil, err
+		}
+		inputFilePath, err := filepath.Abs(inputFile.Name())
+		if err != nil {
+			os.Remove(inputFile.Name())
+			return nil, err
+		}
+
+		_, stderr, err := RunCompiled(compiled.FilePath, inputFilePath, lang)
+		os.Remove(inputFile.Name())
+		if err == nil {
+			continue
+		}
+
+		reports = append(reports, ValidationReport{
+			Test:    tc.Number,
+			Message: failureMessage(stderr, err),
+		})
+	}
+	return reports, nil
+}
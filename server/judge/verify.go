@@ -0,0 +1,142 @@
+package judge
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/khayrultw/go-judge/models"
+	"gorm.io/gorm"
+)
+
+const (
+	VerdictAccepted     = "AC"
+	VerdictWrongAnswer  = "WA"
+	VerdictTimeLimit    = "TLE"
+	VerdictMemoryLimit  = "MLE"
+	VerdictCompileError = "CE"
+	VerdictRuntimeError = "RE"
+)
+
+// ExpectedVerdicts are the tags a reference solution can be attached with.
+var ExpectedVerdicts = []string{VerdictAccepted, VerdictTimeLimit, VerdictWrongAnswer}
+
+type SolutionReport struct {
+	SolutionId uint   `json:"solution_id"`
+	Language   string `json:"language"`
+	Expected   string `json:"expected"`
+	Actual     string `json:"actual"`
+	Status     string `json:"status"`
+	Message    string `json:"message"`
+}
+
+func IsExpectedVerdict(verdict string) bool {
+	for _, v := range ExpectedVerdicts {
+		if v == verdict {
+			return true
+		}
+	}
+	return false
+}
+
+// Verdict maps the status of a judge result to a short verdict code.
+func Verdict(result models.Result) string {
+	switch {
+	case result.Status == "PASS":
+		return VerdictAccepted
+	case result.Status == "FAIL":
+		return VerdictWrongAnswer
+	case result.Status == "Syntax Error":
+		return VerdictCompileError
+	case strings.HasPrefix(result.Status, "Time Limit Exceeded"):
+		return VerdictTimeLimit
+	case strings.HasPrefix(result.Status, "Memory Limit Exceeded"):
+		return VerdictMemoryLimit
+	default:
+		return VerdictRuntimeError
+	}
+}
+
+// VerificationPending is the status of a reference solution waiting to be
+// verified
+const VerificationPending = "pending"
+
+var (
+	verifyMu sync.Mutex
+	// problems with a verification that hasn't started yet
+	verifyQueued = make(map[uint]bool)
+)
+
+// QueueVerification marks the reference solutions of the problem pending
+// and judges them against its tests in the background, with the priority
+// of rejudges. The verdicts are stored with the solutions.
+func QueueVerification(db *gorm.DB, problemId, userId uint) error {
+	err := db.Model(&models.ReferenceSolution{}).Where("problem_id = ?", problemId).Update("status", VerificationPending).Error
+	if err != nil {
+		return fmt.Errorf("Failed to queue verification")
+	}
+	// a verification that hasn't started yet will judge the latest
+	// solutions and tests anyway
+	verifyMu.Lock()
+	queued := verifyQueued[problemId]
+	verifyQueued[problemId] = true
+	verifyMu.Unlock()
+	if !queued {
+		Background(userId, PriorityRejudge, func(ctx context.Context) {
+			verifyProblem(ctx, db, problemId)
+		})
+	}
+	return nil
+}
+
+// verifyProblem judges the current reference solutions of a problem. A
+// solution verified against a later revision meanwhile is left alone.
+func verifyProblem(ctx context.Context, db *gorm.DB, problemId uint) {
+	verifyMu.Lock()
+	delete(verifyQueued, problemId)
+	verifyMu.Unlock()
+
+	var problem models.Problem
+	if err := db.Preload("Solutions").First(&problem, problemId).Error; err != nil {
+		log.Printf("Failed to verify problem %d: %v", problemId, err)
+		return
+	}
+	opts := OptionsFor(problem)
+	opts.Context = ctx
+	for _, solution := range problem.Solutions {
+		result := JudgeWithOptions(solution.SourceCode, problem.TestCasePath, solution.Language, opts)
+		err := db.Model(&models.ReferenceSolution{}).
+			Where("id = ? AND verified_revision <= ?", solution.Id, problem.Revision).
+			Updates(map[string]interface{}{
+				"actual":            Verdict(result),
+				"status":            result.Status,
+				"message":           result.Message,
+				"verified_revision": problem.Revision,
+			}).Error
+		if err != nil {
+			log.Printf("Failed to save the verification of solution %d: %v", solution.Id, err)
+		}
+	}
+}
+
+// Mismatches reports the verified reference solutions whose verdict
+// doesn't match their tag
+func Mismatches(solutions []models.ReferenceSolution) []SolutionReport {
+	mismatches := []SolutionReport{}
+	for _, solution := range solutions {
+		if solution.Status == VerificationPending || solution.Actual == "" || solution.Actual == solution.Expected {
+			continue
+		}
+		mismatches = append(mismatches, SolutionReport{
+			SolutionId: solution.Id,
+			Language:   solution.Language,
+			Expected:   solution.Expected,
+			Actual:     solution.Actual,
+			Status:     solution.Status,
+			Message:    solution.Message,
+		})
+	}
+	return mismatches
+}
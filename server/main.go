@@ -1,14 +1,26 @@
 package main
 
 import (
+	"log"
+	"os"
+
 	"github.com/gin-gonic/gin"
 
+	"github.com/khayrultw/go-judge/cli"
 	"github.com/khayrultw/go-judge/config"
 	"github.com/khayrultw/go-judge/database"
+	"github.com/khayrultw/go-judge/judge"
 	"github.com/khayrultw/go-judge/routes"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		if err := cli.Run(os.Args[1], os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	r := gin.Default()
 	if err := config.LoadConfig(); err != nil {
 		return
@@ -17,6 +29,12 @@ func main() {
 	if err := database.InitDb(); err != nil {
 		return
 	}
+	judge.StartWorkers(database.Db, config.GetConfig().JudgeWorkers)
+	go func() {
+		if health := judge.SelfTest(config.GetConfig().JudgeLanguages); !health.OK {
+			log.Println("The judge self-test failed, see /api/admin/judge/health")
+		}
+	}()
 
 	api := r.Group("/api")
 	{
@@ -24,7 +42,6 @@ func main() {
 	}
 	routes.RegisterClientRoutes(r)
 
-	r.Static("/store", "./store")
 	r.Static("/static", "../client/build/static")
 
 	r.Run("0.0.0.0:8080")
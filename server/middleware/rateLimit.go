@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimit lets each user make at most limit requests in any window of
+// time through the handlers it guards. Every call makes a limiter of its
+// own, so routes don't share their limits.
+func RateLimit(limit int, window time.Duration) gin.HandlerFunc {
+	var mu sync.Mutex
+	requests := make(map[string][]time.Time)
+
+	return func(c *gin.Context) {
+		key := c.ClientIP()
+		if userId, ok := c.Get("userId"); ok {
+			key = fmt.Sprint(userId)
+		}
+		now := time.Now()
+
+		mu.Lock()
+		// only the requests still inside the window count
+		recent := requests[key][:0]
+		for _, t := range requests[key] {
+			if now.Sub(t) < window {
+				recent = append(recent, t)
+			}
+		}
+		if len(recent) >= limit {
+			retryAfter := int((window - now.Sub(recent[0])).Seconds()) + 1
+			requests[key] = recent
+			mu.Unlock()
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": fmt.Sprintf("Too many requests, try again in %d seconds", retryAfter)})
+			return
+		}
+		requests[key] = append(recent, now)
+		for other, times := range requests {
+			if len(times) > 0 && now.Sub(times[len(times)-1]) >= window {
+				delete(requests, other)
+			}
+		}
+		mu.Unlock()
+
+		c.Next()
+	}
+}
@@ -64,3 +64,28 @@ func RequireTokenInQuery(c *gin.Context) {
 	c.Set("role", claims["role"].(string))
 	c.Next()
 }
+
+// AllowTokenInQuery identifies the user from the token in the query, like
+// RequireTokenInQuery, but lets requests without a valid token through as
+// anonymous. Browsers can't send headers for images in a page.
+func AllowTokenInQuery(c *gin.Context) {
+	tokenStr := c.Query("q")
+	if tokenStr == "" {
+		c.Next()
+		return
+	}
+	token, err := jwt.Parse(tokenStr, func(t *jwt.Token) (interface{}, error) {
+		_, ok := t.Method.(*jwt.SigningMethodHMAC)
+		if !ok {
+			return nil, fmt.Errorf("unexpected signing method")
+		}
+		return []byte(config.GetConfig().JWTSecret), nil
+	})
+	if err == nil && token.Valid {
+		if claims, ok := token.Claims.(jwt.MapClaims); ok {
+			c.Set("userId", uint(claims["user_id"].(float64)))
+			c.Set("role", claims["role"].(string))
+		}
+	}
+	c.Next()
+}
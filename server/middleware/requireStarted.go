@@ -10,6 +10,8 @@ import (
 	"github.com/khayrultw/go-judge/models"
 )
 
+// RequireStarted lets a problem be seen once it is in the practice archive
+// or in a contest that has started. Admins can see every problem.
 func RequireStarted(c *gin.Context) {
 	problemIdStr := c.Param("problemId")
 	problemId, err := strconv.ParseUint(problemIdStr, 10, 64)
@@ -24,16 +26,31 @@ func RequireStarted(c *gin.Context) {
 		return
 	}
 
-	var contest models.Contest
-	if err := database.Db.First(&contest, problem.ContestId).Error; err != nil {
-		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "Contest not found"})
+	visible, err := ProblemVisible(problem, c.GetString("role"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to check contest"})
 		return
 	}
 
-	if contest.StartTime.Time.After(time.Now()) {
+	if !visible {
 		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Contest has not started yet"})
 		return
 	}
 
 	c.Next()
 }
+
+// ProblemVisible is the check of RequireStarted, for handlers that get the
+// problem from somewhere else than the path
+func ProblemVisible(problem models.Problem, role string) (bool, error) {
+	if problem.Public || role == "admin" {
+		return true, nil
+	}
+
+	var started int64
+	err := database.Db.Model(&models.ContestProblem{}).
+		Joins("JOIN contests ON contests.id = contest_problems.contest_id").
+		Where("contest_problems.problem_id = ? AND contests.start_time <= ?", problem.Id, time.Now()).
+		Count(&started).Error
+	return started > 0, err
+}
@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/khayrultw/go-judge/config"
+)
+
+// RequireWorker lets in remote judge workers, which authenticate with the
+// JUDGE_WORKER_TOKEN secret instead of a user token
+func RequireWorker(c *gin.Context) {
+	secret := config.GetConfig().JudgeWorkerToken
+	if secret == "" {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Remote workers are disabled"})
+		return
+	}
+	authHeader := c.GetHeader("Authorization")
+	if len(authHeader) < 8 || authHeader[:7] != "Bearer " ||
+		subtle.ConstantTimeCompare([]byte(authHeader[7:]), []byte(secret)) != 1 {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid worker token"})
+		return
+	}
+	c.Next()
+}
@@ -0,0 +1,15 @@
+package models
+
+// Attachment is a file a statement refers to, like an image, a PDF or a
+// sample file. It is served at a stable URL made of the problem id and
+// the file name, so uploading a file with the same name replaces it.
+type Attachment struct {
+	Id          uint       `json:"id"`
+	ProblemId   uint       `json:"problem_id" gorm:"uniqueIndex:idx_attachment_name"`
+	Name        string     `json:"name" gorm:"uniqueIndex:idx_attachment_name"`
+	ContentType string     `json:"content_type"`
+	Size        int64      `json:"size"`
+	Path        string     `json:"-"`
+	URL         string     `json:"url" gorm:"-"`
+	CreatedAt   CustomTime `json:"created_at" gorm:"autoCreateTime"`
+}
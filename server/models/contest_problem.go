@@ -0,0 +1,19 @@
+package models
+
+// ContestProblem links a problem of the archive to a contest. The same
+// problem can be used in any number of contests, each giving it its own
+// label and position.
+type ContestProblem struct {
+	ContestId uint       `json:"contest_id" gorm:"primaryKey;autoIncrement:false"`
+	ProblemId uint       `json:"problem_id" gorm:"primaryKey;autoIncrement:false"`
+	Label     string     `json:"label"`
+	Position  uint8      `json:"position"`
+	Contest   Contest    `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	Problem   Problem    `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	CreatedAt CustomTime `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// DefaultLabel labels problems A, B, C... by their position in the contest
+func DefaultLabel(position uint8) string {
+	return string(rune('A' + int(position)))
+}
@@ -0,0 +1,10 @@
+package models
+
+type Generator struct {
+	Id         uint       `json:"id"`
+	ProblemId  uint       `json:"problem_id"`
+	Name       string     `json:"name" validate:"required" binding:"required"`
+	Language   string     `json:"language" validate:"required" binding:"required"`
+	SourceCode string     `json:"source_code" validate:"required" binding:"required"`
+	CreatedAt  CustomTime `json:"created_at" gorm:"autoCreateTime"`
+}
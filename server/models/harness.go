@@ -0,0 +1,26 @@
+package models
+
+// SolutionPlaceholder is the line of a harness driver that the submitted
+// code replaces
+const SolutionPlaceholder = "{{solution}}"
+
+// Harness turns a problem in one language into a function-signature
+// problem: contestants submit only the function, starting from Template,
+// and it is judged inside Driver, which reads the input, calls it and
+// prints the answer.
+type Harness struct {
+	Language string `json:"language"`
+	Driver   string `json:"driver,omitempty"`
+	Template string `json:"template"`
+}
+
+// HarnessFor returns the harness of the problem for a language, or nil if
+// code in that language is judged as a whole program
+func (p Problem) HarnessFor(language string) *Harness {
+	for i := range p.Harnesses {
+		if p.Harnesses[i].Language == language {
+			return &p.Harnesses[i]
+		}
+	}
+	return nil
+}
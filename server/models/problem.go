@@ -1,12 +1,57 @@
 package models
 
+// The types of problem. Output-only problems are solved by submitting an
+// output for each test instead of code.
+const (
+	ProblemTypeStandard   = "standard"
+	ProblemTypeOutputOnly = "output_only"
+)
+
 type Problem struct {
-	Id            uint         `json:"id"`
-	Title         string       `json:"title" validate:"required" binding:"required"`
-	ContestId     uint         `json:"contest_id" validate:"required" binding:"required"`
-	Statement     string       `json:"statement" validate:"required" binding:"required"`
-	TestCasePath  string       `json:"test_case_path" validate:"required" binding:"required"`
-	ProblemNumber uint8        `json:"problem_number" validate:"required" binding:"required"`
-	Submissions   []Submission `gorm:"foreignKey:ProblemId;references:Id" json:"-"`
-	CreatedAt     CustomTime   `json:"created_at" gorm:"autoCreateTime"`
+	Id                uint   `json:"id"`
+	Title             string `json:"title" validate:"required" binding:"required"`
+	Statement         string `json:"statement"` // the sections as markdown
+	StatementSections `gorm:"embedded"`
+	SampleTests       []uint              `json:"sample_tests" gorm:"serializer:json"` // numbers of the tests shown as samples
+	TestCasePath      string              `json:"test_case_path" validate:"required" binding:"required"`
+	TimeLimit         uint                `json:"time_limit"`
+	MemoryLimit       uint                `json:"memory_limit"`
+	CheckerPath       string              `json:"checker_path"`
+	CheckerLanguage   string              `json:"checker_language"`
+	CheckerProtocol   string              `json:"checker_protocol"`
+	ValidatorPath     string              `json:"validator_path"`
+	ValidatorLanguage string              `json:"validator_language"`
+	GeneratorScript   string              `json:"generator_script"`
+	Harnesses         []Harness           `json:"harnesses,omitempty" gorm:"serializer:json"` // for function-signature problems
+	Type              string              `json:"type" gorm:"default:standard"`
+	InputFile         string              `json:"input_file"`  // like input.txt, stdin if empty
+	OutputFile        string              `json:"output_file"` // like output.txt, stdout if empty
+	TestVersion       uint                `json:"test_version"`
+	Revision          uint                `json:"revision"` // see ProblemRevision
+	Public            bool                `json:"public"`   // listed in the practice archive
+	Difficulty        uint                `json:"difficulty"`
+	Source            string              `json:"source"` // where the problem was first used
+	Tags              []Tag               `gorm:"many2many:problem_tags" json:"tags"`
+	DefaultLanguage   string              `json:"default_language" gorm:"default:en"`          // of Title and Statement
+	Statements        []ProblemStatement  `gorm:"foreignKey:ProblemId;references:Id" json:"-"` // translations
+	Submissions       []Submission        `gorm:"foreignKey:ProblemId;references:Id" json:"-"`
+	Solutions         []ReferenceSolution `gorm:"foreignKey:ProblemId;references:Id" json:"-"`
+	Generators        []Generator         `gorm:"foreignKey:ProblemId;references:Id" json:"-"`
+	CreatedAt         CustomTime          `json:"created_at" gorm:"autoCreateTime"`
+
+	// set only when the problem is loaded through one of the contests it is
+	// used in, from its ContestProblem
+	ContestId     uint   `json:"contest_id,omitempty" gorm:"->;-:migration"`
+	ProblemNumber uint8  `json:"problem_number" gorm:"->;-:migration"`
+	Label         string `json:"label,omitempty" gorm:"->;-:migration"`
+
+	// set only when searching the archive, for the current user
+	Solved bool `json:"solved,omitempty" gorm:"->;-:migration"`
+
+	// set only by GetProblem: the language Title and Statement are in,
+	// every language the statement is available in, and the samples, which
+	// Statement then includes
+	Language  string   `json:"language,omitempty" gorm:"-"`
+	Languages []string `json:"languages,omitempty" gorm:"-"`
+	Samples   []Sample `json:"samples,omitempty" gorm:"-"`
 }
@@ -0,0 +1,96 @@
+package models
+
+import (
+	"reflect"
+	"slices"
+)
+
+// ProblemRevision is an immutable snapshot of what a problem is judged
+// with. Every edit of a problem adds a revision instead of changing the
+// previous one, and submissions record the revision they were judged
+// against.
+type ProblemRevision struct {
+	Id                uint   `json:"id"`
+	ProblemId         uint   `json:"problem_id" gorm:"uniqueIndex:idx_problem_revision"`
+	Revision          uint   `json:"revision" gorm:"uniqueIndex:idx_problem_revision"`
+	Title             string `json:"title"`
+	Statement         string `json:"statement"`
+	StatementSections `gorm:"embedded"`
+	SampleTests       []uint     `json:"sample_tests" gorm:"serializer:json"`
+	TestCasePath      string     `json:"test_case_path"`
+	TestVersion       uint       `json:"test_version"`
+	TimeLimit         uint       `json:"time_limit"`
+	MemoryLimit       uint       `json:"memory_limit"`
+	CheckerPath       string     `json:"checker_path"`
+	CheckerLanguage   string     `json:"checker_language"`
+	CheckerProtocol   string     `json:"checker_protocol"`
+	ValidatorPath     string     `json:"validator_path"`
+	ValidatorLanguage string     `json:"validator_language"`
+	Harnesses         []Harness  `json:"harnesses,omitempty" gorm:"serializer:json"`
+	Type              string     `json:"type"`
+	InputFile         string     `json:"input_file"`
+	OutputFile        string     `json:"output_file"`
+	AuthorId          uint       `json:"author_id"`
+	Comment           string     `json:"comment"`
+	CreatedAt         CustomTime `json:"created_at" gorm:"autoCreateTime"`
+
+	AuthorName string `json:"author_name,omitempty" gorm:"->;-:migration"`
+}
+
+// RevisionOf snapshots the current state of the problem
+func RevisionOf(problem Problem) ProblemRevision {
+	return ProblemRevision{
+		ProblemId:         problem.Id,
+		Revision:          problem.Revision,
+		Title:             problem.Title,
+		Statement:         problem.Statement,
+		StatementSections: problem.StatementSections,
+		SampleTests:       problem.SampleTests,
+		TestCasePath:      problem.TestCasePath,
+		TestVersion:       problem.TestVersion,
+		TimeLimit:         problem.TimeLimit,
+		MemoryLimit:       problem.MemoryLimit,
+		CheckerPath:       problem.CheckerPath,
+		CheckerLanguage:   problem.CheckerLanguage,
+		CheckerProtocol:   problem.CheckerProtocol,
+		ValidatorPath:     problem.ValidatorPath,
+		ValidatorLanguage: problem.ValidatorLanguage,
+		Harnesses:         problem.Harnesses,
+		Type:              problem.Type,
+		InputFile:         problem.InputFile,
+		OutputFile:        problem.OutputFile,
+	}
+}
+
+// Same reports whether two snapshots judge alike, taking a nil list for an
+// empty one
+func (r ProblemRevision) Same(other ProblemRevision) bool {
+	if !slices.Equal(r.SampleTests, other.SampleTests) || !slices.Equal(r.Harnesses, other.Harnesses) {
+		return false
+	}
+	r.SampleTests, other.SampleTests = nil, nil
+	r.Harnesses, other.Harnesses = nil, nil
+	return reflect.DeepEqual(r, other)
+}
+
+// ApplyTo restores the problem to the revision. TestVersion is left alone,
+// it numbers the test files and must keep growing so that no file is
+// written twice.
+func (r ProblemRevision) ApplyTo(problem *Problem) {
+	problem.Title = r.Title
+	problem.Statement = r.Statement
+	problem.StatementSections = r.StatementSections
+	problem.SampleTests = r.SampleTests
+	problem.TestCasePath = r.TestCasePath
+	problem.TimeLimit = r.TimeLimit
+	problem.MemoryLimit = r.MemoryLimit
+	problem.CheckerPath = r.CheckerPath
+	problem.CheckerLanguage = r.CheckerLanguage
+	problem.CheckerProtocol = r.CheckerProtocol
+	problem.ValidatorPath = r.ValidatorPath
+	problem.ValidatorLanguage = r.ValidatorLanguage
+	problem.Harnesses = r.Harnesses
+	problem.Type = r.Type
+	problem.InputFile = r.InputFile
+	problem.OutputFile = r.OutputFile
+}
@@ -0,0 +1,18 @@
+package models
+
+type ReferenceSolution struct {
+	Id         uint       `json:"id"`
+	ProblemId  uint       `json:"problem_id"`
+	Language   string     `json:"language" validate:"required" binding:"required"`
+	SourceCode string     `json:"source_code" validate:"required" binding:"required"`
+	Expected   string     `json:"expected" validate:"required" binding:"required"`
+	CreatedAt  CustomTime `json:"created_at" gorm:"autoCreateTime"`
+
+	// the last verification against the tests of the problem: Status is
+	// pending while it waits, then Actual is the verdict the solution got
+	// on the tests of VerifiedRevision
+	Actual           string `json:"actual,omitempty"`
+	Status           string `json:"status,omitempty"`
+	Message          string `json:"message,omitempty"`
+	VerifiedRevision uint   `json:"verified_revision,omitempty"`
+}
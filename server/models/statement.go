@@ -0,0 +1,62 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// StatementSections are the parts of a statement in one language. The
+// samples come from the tests of the problem instead.
+type StatementSections struct {
+	Legend       string `json:"legend"`
+	InputFormat  string `json:"input_format"`
+	OutputFormat string `json:"output_format"`
+	Constraints  string `json:"constraints"`
+	Notes        string `json:"notes"`
+}
+
+// Sample is a test shown with the statement
+type Sample struct {
+	Input  string `json:"input"`
+	Output string `json:"output"`
+}
+
+// Markdown joins the sections into a single statement, with a heading for
+// each section after the legend. The samples go before the notes.
+func (s StatementSections) Markdown(samples []Sample) string {
+	var parts []string
+	add := func(heading, content string) {
+		content = strings.TrimSpace(content)
+		if content == "" {
+			return
+		}
+		if heading != "" {
+			parts = append(parts, "## "+heading)
+		}
+		parts = append(parts, content)
+	}
+
+	add("", s.Legend)
+	add("Input", s.InputFormat)
+	add("Output", s.OutputFormat)
+	add("Constraints", s.Constraints)
+	if len(samples) > 0 {
+		parts = append(parts, "## Examples")
+		for i, sample := range samples {
+			parts = append(parts, fmt.Sprintf("### Example %d\n\nInput\n\n```\n%s\n```\n\nOutput\n\n```\n%s\n```", i+1, sample.Input, sample.Output))
+		}
+	}
+	add("Notes", s.Notes)
+	return strings.Join(parts, "\n\n")
+}
+
+// ProblemStatement is a translation of a problem statement. The statement
+// in the default language of the problem is kept in the problem itself.
+type ProblemStatement struct {
+	Id                uint   `json:"id"`
+	ProblemId         uint   `json:"problem_id" gorm:"uniqueIndex:idx_statement_language"`
+	Language          string `json:"language" gorm:"uniqueIndex:idx_statement_language"`
+	Title             string `json:"title"`
+	StatementSections `gorm:"embedded"`
+	CreatedAt         CustomTime `json:"created_at" gorm:"autoCreateTime"`
+}
@@ -1,15 +1,48 @@
 package models
 
+// LanguageOutput is the language of submissions to output-only problems,
+// which have outputs instead of source code
+const LanguageOutput = "output"
+
+// StatusCancelled is the status of a submission withdrawn before its
+// verdict. It doesn't count in the standings.
+const StatusCancelled = "Cancelled"
+
 type Submission struct {
-	Id         uint       `json:"id"`
-	UserId     uint       `json:"user_id" validate:"required"`
-	ProblemId  uint       `json:"problem_id" validate:"required"`
-	ContestId  uint       `json:"contest_id" validate:"required" binrding:"required"`
-	SourceCode string     `json:"source_code" validate:"required" binding:"required"`
-	Language   string     `json:"language" validate:"required" binding:"required"`
-	Status     string     `json:"status" gorm:"default:pending"` 
-	Message    string     `json:"message"`
-	CreatedAt  CustomTime `json:"created_at" gorm:"autoCreateTime"`
+	Id              uint       `json:"id"`
+	UserId          uint       `json:"user_id" validate:"required"`
+	ProblemId       uint       `json:"problem_id" validate:"required"`
+	ContestId       uint       `json:"contest_id" validate:"required" binrding:"required"`
+	SourceCode      string     `json:"source_code"`
+	Language        string     `json:"language" validate:"required" binding:"required"`
+	Status          string     `json:"status" gorm:"default:pending"`
+	Message         string     `json:"message"`
+	ProblemRevision uint       `json:"problem_revision"`
+	CreatedAt       CustomTime `json:"created_at" gorm:"autoCreateTime"`
+
+	// the files of a multi-file submission, which are built together
+	// instead of SourceCode
+	Files []SubmissionFile `json:"files,omitempty" gorm:"serializer:json"`
+	Tests []TestResult     `json:"tests,omitempty" gorm:"foreignKey:SubmissionId;references:Id"`
+
+	// the verdict of the judge, kept while an admin overrides Status
+	AutomaticStatus string `json:"automatic_status,omitempty" gorm:"default:''"`
+
+	// when the submission entered the judge queue, when the judge started
+	// to compile and to run it and when it had a verdict
+	QueuedAt         *CustomTime `json:"queued_at,omitempty"`
+	CompileStartedAt *CustomTime `json:"compile_started_at,omitempty"`
+	RunStartedAt     *CustomTime `json:"run_started_at,omitempty"`
+	FinishedAt       *CustomTime `json:"finished_at,omitempty"`
+	// 1 for the next submission to be judged, 0 when not queued
+	QueuePosition int `json:"queue_position,omitempty" gorm:"-"`
+}
+
+// SubmissionFile is one source file of a multi-file submission. Name is a
+// path like src/Main.java.
+type SubmissionFile struct {
+	Name    string `json:"name"`
+	Content string `json:"content"`
 }
 
 type SubmissionWithProblem struct {
@@ -0,0 +1,13 @@
+package models
+
+// SubmissionHistory keeps a verdict a submission had before it was judged
+// again
+type SubmissionHistory struct {
+	Id              uint       `json:"id"`
+	SubmissionId    uint       `json:"submission_id" gorm:"index"`
+	Status          string     `json:"status"`
+	Message         string     `json:"message"`
+	ProblemRevision uint       `json:"problem_revision"`
+	RejudgedBy      uint       `json:"rejudged_by"`
+	CreatedAt       CustomTime `json:"created_at" gorm:"autoCreateTime"`
+}
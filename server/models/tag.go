@@ -0,0 +1,8 @@
+package models
+
+// Tag is a topic like dp, graphs or math that archive problems are
+// filtered by
+type Tag struct {
+	Id   uint   `json:"id"`
+	Name string `json:"name" gorm:"uniqueIndex"`
+}
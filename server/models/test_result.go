@@ -0,0 +1,10 @@
+package models
+
+// TestResult is the verdict of a submission on one test
+type TestResult struct {
+	Id           uint   `json:"id"`
+	SubmissionId uint   `json:"submission_id" gorm:"index"`
+	Test         int    `json:"test"`
+	Verdict      string `json:"verdict"`
+	Message      string `json:"message"`
+}
@@ -0,0 +1,18 @@
+package models
+
+// StatusDisqualified is the status of a submission an admin disqualified.
+// It doesn't count in the standings, not even as a rejected attempt.
+const StatusDisqualified = "Disqualified"
+
+// VerdictOverride records an admin changing the verdict of a submission by
+// hand, or undoing it
+type VerdictOverride struct {
+	Id             uint       `json:"id"`
+	SubmissionId   uint       `json:"submission_id" gorm:"index"`
+	ContestId      uint       `json:"contest_id" gorm:"index"`
+	Status         string     `json:"status"`
+	PreviousStatus string     `json:"previous_status"`
+	Reason         string     `json:"reason"`
+	UserId         uint       `json:"user_id"`
+	CreatedAt      CustomTime `json:"created_at" gorm:"autoCreateTime"`
+}
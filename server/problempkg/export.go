@@ -0,0 +1,299 @@
+package problempkg
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+
+	"github.com/khayrultw/go-judge/judge"
+	"github.com/khayrultw/go-judge/models"
+	"gopkg.in/yaml.v3"
+)
+
+// ExtrasFile describes the parts of a problem the Kattis format has no place
+// for, like testlib checkers, validators and generators. Kattis tools ignore
+// it.
+const ExtrasFile = "oto-judge.yaml"
+
+const extrasVersion = 1
+
+type extrasProgram struct {
+	File     string `yaml:"file"`
+	Language string `yaml:"language"`
+	Protocol string `yaml:"protocol,omitempty"`
+	Name     string `yaml:"name,omitempty"`
+}
+
+type extrasHarness struct {
+	Language string `yaml:"language"`
+	Driver   string `yaml:"driver"`
+	Template string `yaml:"template,omitempty"`
+}
+
+// extrasStatement keeps the sections of a statement, which the Kattis
+// statement has only as headings
+type extrasStatement struct {
+	Language     string `yaml:"language"`
+	Legend       string `yaml:"legend,omitempty"`
+	InputFormat  string `yaml:"input_format,omitempty"`
+	OutputFormat string `yaml:"output_format,omitempty"`
+	Constraints  string `yaml:"constraints,omitempty"`
+	Notes        string `yaml:"notes,omitempty"`
+}
+
+func newExtrasStatement(language string, sections models.StatementSections) *extrasStatement {
+	if sections == (models.StatementSections{}) {
+		return nil
+	}
+	return &extrasStatement{
+		Language:     language,
+		Legend:       sections.Legend,
+		InputFormat:  sections.InputFormat,
+		OutputFormat: sections.OutputFormat,
+		Constraints:  sections.Constraints,
+		Notes:        sections.Notes,
+	}
+}
+
+func (s extrasStatement) sections() models.StatementSections {
+	return models.StatementSections{
+		Legend:       s.Legend,
+		InputFormat:  s.InputFormat,
+		OutputFormat: s.OutputFormat,
+		Constraints:  s.Constraints,
+		Notes:        s.Notes,
+	}
+}
+
+type extras struct {
+	Version         int               `yaml:"version"`
+	Checker         *extrasProgram    `yaml:"checker,omitempty"`
+	Validator       *extrasProgram    `yaml:"validator,omitempty"`
+	Generators      []extrasProgram   `yaml:"generators,omitempty"`
+	GeneratorScript string            `yaml:"generator_script,omitempty"`
+	Difficulty      uint              `yaml:"difficulty,omitempty"`
+	Harnesses       []extrasHarness   `yaml:"harnesses,omitempty"`
+	InputFile       string            `yaml:"input_file,omitempty"`
+	OutputFile      string            `yaml:"output_file,omitempty"`
+	Statements      []extrasStatement `yaml:"statements,omitempty"`
+	// the tests in judging order, like data/secret/001, since Kattis tools
+	// read the samples first
+	Tests []string `yaml:"tests,omitempty"`
+}
+
+type kattisLimits struct {
+	TimeLimit float64 `yaml:"time_limit"`
+	Memory    uint    `yaml:"memory"`
+}
+
+type kattisDescriptor struct {
+	FormatVersion string            `yaml:"problem_format_version"`
+	Name          map[string]string `yaml:"name"`
+	Limits        kattisLimits      `yaml:"limits"`
+	Type          string            `yaml:"type,omitempty"`
+	Source        string            `yaml:"source,omitempty"`
+	Keywords      []string          `yaml:"keywords,omitempty"`
+}
+
+var submissionDirs = map[string]string{
+	judge.VerdictAccepted:    "accepted",
+	judge.VerdictWrongAnswer: "wrong_answer",
+	judge.VerdictTimeLimit:   "time_limit_exceeded",
+}
+
+// Write exports the problem as a Kattis problem package with an extras
+// file. The solutions, generators, tags and statements of the problem must
+// be loaded.
+func Write(w io.Writer, problem models.Problem) error {
+	zw := zip.NewWriter(w)
+
+	timeLimit, memoryLimit := problem.TimeLimit, problem.MemoryLimit
+	if timeLimit == 0 {
+		timeLimit = judge.DefaultTimeLimit
+	}
+	if memoryLimit == 0 {
+		memoryLimit = judge.DefaultMemoryLimit
+	}
+	var keywords []string
+	for _, tag := range problem.Tags {
+		keywords = append(keywords, tag.Name)
+	}
+	language := problem.DefaultLanguage
+	if language == "" {
+		language = "en"
+	}
+	problemType := ""
+	if problem.Type == models.ProblemTypeOutputOnly {
+		problemType = kattisSubmitAnswer
+	}
+	names := map[string]string{language: problem.Title}
+	for _, statement := range problem.Statements {
+		names[statement.Language] = statement.Title
+	}
+	descriptor, err := yaml.Marshal(kattisDescriptor{
+		FormatVersion: "2023-07-draft",
+		Name:          names,
+		Limits:        kattisLimits{TimeLimit: float64(timeLimit) / 1000, Memory: memoryLimit},
+		Type:          problemType,
+		Source:        problem.Source,
+		Keywords:      keywords,
+	})
+	if err != nil {
+		return err
+	}
+	if err := writeZipFile(zw, "problem.yaml", string(descriptor)); err != nil {
+		return err
+	}
+
+	if err := writeZipFile(zw, "statement/problem."+language+".md", problem.Statement); err != nil {
+		return err
+	}
+	for _, statement := range problem.Statements {
+		if err := writeZipFile(zw, "statement/problem."+statement.Language+".md", statement.Markdown(nil)); err != nil {
+			return err
+		}
+	}
+
+	content, err := os.ReadFile(problem.TestCasePath)
+	if err != nil {
+		return fmt.Errorf("Failed to read testcase file")
+	}
+	tests, err := judge.ParseTestCases(string(content))
+	if err != nil {
+		return err
+	}
+	var testNames []string
+	for i, tc := range tests {
+		group := "secret"
+		if slices.Contains(problem.SampleTests, uint(i+1)) {
+			group = "sample"
+		}
+		name := fmt.Sprintf("data/%s/%03d", group, i+1)
+		testNames = append(testNames, name)
+		if err := writeZipFile(zw, name+".in", tc.Input+"\n"); err != nil {
+			return err
+		}
+		if err := writeZipFile(zw, name+".ans", tc.Output+"\n"); err != nil {
+			return err
+		}
+	}
+
+	for i, solution := range problem.Solutions {
+		dir, ok := submissionDirs[solution.Expected]
+		if !ok {
+			continue
+		}
+		name := fmt.Sprintf("submissions/%s/solution_%d.%s", dir, i+1, solution.Language)
+		if err := writeZipFile(zw, name, solution.SourceCode); err != nil {
+			return err
+		}
+	}
+
+	ext := extras{
+		Version:         extrasVersion,
+		GeneratorScript: problem.GeneratorScript,
+		Difficulty:      problem.Difficulty,
+		InputFile:       problem.InputFile,
+		OutputFile:      problem.OutputFile,
+		Tests:           testNames,
+	}
+
+	// the statements are exported as markdown, which imports as a legend
+	// only
+	if statement := newExtrasStatement(language, problem.StatementSections); statement != nil {
+		ext.Statements = append(ext.Statements, *statement)
+	}
+	for _, translation := range problem.Statements {
+		if statement := newExtrasStatement(translation.Language, translation.StatementSections); statement != nil {
+			ext.Statements = append(ext.Statements, *statement)
+		}
+	}
+
+	// Kattis output validators go where Kattis tools look for them, any
+	// other checker only in the extras
+	if problem.CheckerPath != "" {
+		dir := "oto-judge"
+		if problem.CheckerProtocol == judge.CheckerKattis {
+			dir = "output_validator"
+		}
+		name := path.Join(dir, "checker."+problem.CheckerLanguage)
+		if err := copyToZip(zw, name, problem.CheckerPath); err != nil {
+			return err
+		}
+		ext.Checker = &extrasProgram{File: name, Language: problem.CheckerLanguage, Protocol: problem.CheckerProtocol}
+	}
+
+	if problem.ValidatorPath != "" {
+		name := path.Join("oto-judge", "validator."+problem.ValidatorLanguage)
+		if err := copyToZip(zw, name, problem.ValidatorPath); err != nil {
+			return err
+		}
+		ext.Validator = &extrasProgram{File: name, Language: problem.ValidatorLanguage}
+	}
+
+	for _, generator := range problem.Generators {
+		name := path.Join("oto-judge", "generators", generator.Name+"."+generator.Language)
+		if err := writeZipFile(zw, name, generator.SourceCode); err != nil {
+			return err
+		}
+		ext.Generators = append(ext.Generators, extrasProgram{File: name, Language: generator.Language, Name: generator.Name})
+	}
+
+	for _, harness := range problem.Harnesses {
+		entry := extrasHarness{Language: harness.Language, Driver: path.Join("oto-judge", "harness", "driver."+harness.Language)}
+		if err := writeZipFile(zw, entry.Driver, harness.Driver); err != nil {
+			return err
+		}
+		if harness.Template != "" {
+			entry.Template = path.Join("oto-judge", "harness", "template."+harness.Language)
+			if err := writeZipFile(zw, entry.Template, harness.Template); err != nil {
+				return err
+			}
+		}
+		ext.Harnesses = append(ext.Harnesses, entry)
+	}
+
+	// headers like testlib.h are kept next to the problem files
+	if problem.CheckerPath != "" || problem.ValidatorPath != "" || len(problem.Generators) > 0 {
+		headers, err := filepath.Glob(filepath.Join(filepath.Dir(problem.TestCasePath), "*.h"))
+		if err != nil {
+			return err
+		}
+		for _, header := range headers {
+			if err := copyToZip(zw, path.Join("oto-judge", filepath.Base(header)), header); err != nil {
+				return err
+			}
+		}
+	}
+
+	extrasContent, err := yaml.Marshal(ext)
+	if err != nil {
+		return err
+	}
+	if err := writeZipFile(zw, ExtrasFile, string(extrasContent)); err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
+
+func writeZipFile(zw *zip.Writer, name, content string) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, content)
+	return err
+}
+
+func copyToZip(zw *zip.Writer, name, source string) error {
+	content, err := os.ReadFile(source)
+	if err != nil {
+		return fmt.Errorf("Failed to read %s", filepath.Base(source))
+	}
+	return writeZipFile(zw, name, string(content))
+}
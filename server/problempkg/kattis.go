@@ -0,0 +1,365 @@
+package problempkg
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/khayrultw/go-judge/judge"
+	"github.com/khayrultw/go-judge/models"
+	"gopkg.in/yaml.v3"
+)
+
+type kattisConfig struct {
+	// Name is a string in older packages and a map from language to name
+	// in newer ones
+	Name   interface{} `yaml:"name"`
+	Limits struct {
+		TimeLimit float64 `yaml:"time_limit"`
+		Memory    uint    `yaml:"memory"`
+	} `yaml:"limits"`
+	// Source is a string or a map with a name and a url, and Keywords a
+	// space separated string in older packages and a list in newer ones
+	Source   interface{} `yaml:"source"`
+	Keywords interface{} `yaml:"keywords"`
+	// Type is a string or a list of types like submit-answer
+	Type interface{} `yaml:"type"`
+}
+
+// kattisSubmitAnswer is the Kattis type of output-only problems
+const kattisSubmitAnswer = "submit-answer"
+
+// kattisVerdicts maps the submissions directories of a Kattis package to
+// expected verdicts
+var kattisVerdicts = []struct {
+	Dir      string
+	Expected string
+}{
+	{"accepted", judge.VerdictAccepted},
+	{"wrong_answer", judge.VerdictWrongAnswer},
+	{"time_limit_exceeded", judge.VerdictTimeLimit},
+}
+
+func readKattis(a archive) (*Package, error) {
+	descriptor, err := a.read("problem.yaml")
+	if err != nil {
+		return nil, err
+	}
+	var config kattisConfig
+	if err := yaml.Unmarshal([]byte(descriptor), &config); err != nil {
+		return nil, fmt.Errorf("Invalid problem.yaml: %v", err)
+	}
+
+	pkg := &Package{Resources: make(map[string]string), CheckerProtocol: judge.CheckerKattis, Type: models.ProblemTypeStandard}
+
+	language := "en"
+	switch name := config.Name.(type) {
+	case string:
+		pkg.Title = name
+	case map[string]interface{}:
+		if _, ok := name[language]; !ok && len(name) > 0 {
+			language = sortedKeys(name)[0]
+		}
+		pkg.Title = fmt.Sprint(name[language])
+		for _, lang := range sortedKeys(name) {
+			if lang == language {
+				continue
+			}
+			statement := readKattisStatementIn(a, lang)
+			if statement == "" {
+				pkg.warn("The %s statement was not found", lang)
+				continue
+			}
+			pkg.Statements = append(pkg.Statements, models.ProblemStatement{
+				Language:          lang,
+				Title:             fmt.Sprint(name[lang]),
+				StatementSections: models.StatementSections{Legend: statement},
+			})
+		}
+	}
+	pkg.Language = language
+	pkg.TimeLimit = uint(config.Limits.TimeLimit * 1000)
+	pkg.MemoryLimit = config.Limits.Memory
+	pkg.Sections.Legend = readKattisStatement(a, language)
+
+	switch source := config.Source.(type) {
+	case string:
+		pkg.Source = source
+	case map[string]interface{}:
+		if name, ok := source["name"]; ok {
+			pkg.Source = fmt.Sprint(name)
+		}
+	}
+	switch problemType := config.Type.(type) {
+	case string:
+		if problemType == kattisSubmitAnswer {
+			pkg.Type = models.ProblemTypeOutputOnly
+		}
+	case []interface{}:
+		for _, t := range problemType {
+			if fmt.Sprint(t) == kattisSubmitAnswer {
+				pkg.Type = models.ProblemTypeOutputOnly
+			}
+		}
+	}
+	switch keywords := config.Keywords.(type) {
+	case string:
+		pkg.Tags = strings.Fields(keywords)
+	case []interface{}:
+		for _, keyword := range keywords {
+			pkg.Tags = append(pkg.Tags, fmt.Sprint(keyword))
+		}
+	}
+
+	var testNames []string
+	for _, group := range []string{"data/sample", "data/secret"} {
+		for _, name := range a.list(group) {
+			if path.Ext(name) != ".in" {
+				continue
+			}
+			input, err := a.read(name)
+			if err != nil {
+				return nil, err
+			}
+			answer, err := a.read(strings.TrimSuffix(name, ".in") + ".ans")
+			if err != nil {
+				return nil, err
+			}
+			testNames = append(testNames, strings.TrimSuffix(name, ".in"))
+			pkg.Tests = append(pkg.Tests, judge.TestCase{
+				Number: len(pkg.Tests) + 1,
+				Input:  strings.TrimSpace(input),
+				Output: strings.TrimSpace(answer),
+			})
+			if group == "data/sample" {
+				pkg.SampleTests = append(pkg.SampleTests, uint(len(pkg.Tests)))
+			}
+		}
+	}
+	if len(pkg.Tests) == 0 {
+		return nil, fmt.Errorf("The package has no tests under data/")
+	}
+
+	// the legacy format keeps output validators in output_validators/<name>/
+	// and the current one in output_validator/
+	for _, dir := range []string{"output_validator", "output_validators"} {
+		checker, resources, err := readKattisProgram(a, dir)
+		if err != nil {
+			return nil, err
+		}
+		if checker != nil {
+			pkg.Checker = checker
+			for name, content := range resources {
+				pkg.Resources[name] = content
+			}
+			break
+		}
+	}
+
+	if len(a.list("input_validators")) > 0 || len(a.list("input_format_validators")) > 0 {
+		pkg.warn("Kattis input validators are not imported, they use a different exit code convention")
+	}
+
+	if a.has(ExtrasFile) {
+		if err := readExtras(a, pkg, testNames); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, verdict := range kattisVerdicts {
+		dir := path.Join("submissions", verdict.Dir)
+		for _, name := range a.list(dir) {
+			lang := languageForExtension(name)
+			if lang == "" || path.Dir(name) != dir {
+				pkg.warn("Solution %s was not imported", name)
+				continue
+			}
+			source, err := a.read(name)
+			if err != nil {
+				return nil, err
+			}
+			pkg.Solutions = append(pkg.Solutions, models.ReferenceSolution{
+				Language:   lang,
+				SourceCode: source,
+				Expected:   verdict.Expected,
+			})
+		}
+	}
+
+	return pkg, nil
+}
+
+// readExtras restores what an oto-judge export keeps outside of the Kattis
+// layout. testNames are the names of pkg.Tests without the extension.
+func readExtras(a archive, pkg *Package, testNames []string) error {
+	content, err := a.read(ExtrasFile)
+	if err != nil {
+		return err
+	}
+	var ext extras
+	if err := yaml.Unmarshal([]byte(content), &ext); err != nil {
+		return fmt.Errorf("Invalid %s: %v", ExtrasFile, err)
+	}
+	if ext.Version > extrasVersion {
+		return fmt.Errorf("%s version %d is newer than this server supports", ExtrasFile, ext.Version)
+	}
+
+	if ext.Checker != nil {
+		if pkg.Checker, err = a.readSource(ext.Checker.File, ext.Checker.Language); err != nil {
+			return err
+		}
+		pkg.CheckerProtocol = ext.Checker.Protocol
+	}
+	if ext.Validator != nil {
+		if pkg.Validator, err = a.readSource(ext.Validator.File, ext.Validator.Language); err != nil {
+			return err
+		}
+	}
+	for _, generator := range ext.Generators {
+		source, err := a.read(generator.File)
+		if err != nil {
+			return err
+		}
+		pkg.Generators = append(pkg.Generators, models.Generator{
+			Name:       generator.Name,
+			Language:   generator.Language,
+			SourceCode: source,
+		})
+	}
+	for _, entry := range ext.Harnesses {
+		harness := models.Harness{Language: entry.Language}
+		if harness.Driver, err = a.read(entry.Driver); err != nil {
+			return err
+		}
+		if entry.Template != "" {
+			if harness.Template, err = a.read(entry.Template); err != nil {
+				return err
+			}
+		}
+		pkg.Harnesses = append(pkg.Harnesses, harness)
+	}
+	pkg.GeneratorScript = ext.GeneratorScript
+	pkg.Difficulty = ext.Difficulty
+	pkg.InputFile = ext.InputFile
+	pkg.OutputFile = ext.OutputFile
+
+	if len(ext.Tests) > 0 {
+		pkg.orderTests(testNames, ext.Tests)
+	}
+
+	for _, statement := range ext.Statements {
+		if statement.Language == pkg.Language {
+			pkg.Sections = statement.sections()
+			continue
+		}
+		for i := range pkg.Statements {
+			if pkg.Statements[i].Language == statement.Language {
+				pkg.Statements[i].StatementSections = statement.sections()
+			}
+		}
+	}
+
+	for _, name := range a.list("oto-judge") {
+		if path.Ext(name) != ".h" {
+			continue
+		}
+		if pkg.Resources[path.Base(name)], err = a.read(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// orderTests puts the tests, named by names, in the order of order and
+// numbers them again. The Kattis order is kept if the two don't list the
+// same tests.
+func (p *Package) orderTests(names, order []string) {
+	index := make(map[string]int, len(names))
+	for i, name := range names {
+		index[name] = i
+	}
+	tests := make([]judge.TestCase, 0, len(order))
+	var samples []uint
+	for _, name := range order {
+		i, ok := index[name]
+		if !ok {
+			break
+		}
+		delete(index, name)
+		tc := p.Tests[i]
+		tc.Number = len(tests) + 1
+		tests = append(tests, tc)
+		if path.Dir(name) == "data/sample" {
+			samples = append(samples, uint(tc.Number))
+		}
+	}
+	if len(tests) != len(p.Tests) {
+		p.warn("The tests in %s don't match data/, they are in the Kattis order", ExtrasFile)
+		return
+	}
+	p.Tests, p.SampleTests = tests, samples
+}
+
+// readKattisProgram finds the source of a single-file program under dir,
+// along with the headers next to it
+func readKattisProgram(a archive, dir string) (*SourceFile, map[string]string, error) {
+	var program *SourceFile
+	resources := make(map[string]string)
+	for _, name := range a.list(dir) {
+		if path.Ext(name) == ".h" {
+			content, err := a.read(name)
+			if err != nil {
+				return nil, nil, err
+			}
+			resources[path.Base(name)] = content
+			continue
+		}
+		lang := languageForExtension(name)
+		if program != nil || lang == "" {
+			continue
+		}
+		source, err := a.readSource(name, lang)
+		if err != nil {
+			return nil, nil, err
+		}
+		program = source
+	}
+	return program, resources, nil
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// readKattisStatementIn reads the statement of a language, without falling
+// back to the statement without a language
+func readKattisStatementIn(a archive, language string) string {
+	for _, dir := range []string{"statement", "problem_statement"} {
+		for _, name := range []string{"problem." + language + ".md", "problem." + language + ".tex"} {
+			if statement, err := a.read(path.Join(dir, name)); err == nil {
+				return statement
+			}
+		}
+	}
+	return ""
+}
+
+func readKattisStatement(a archive, language string) string {
+	if statement := readKattisStatementIn(a, language); statement != "" {
+		return statement
+	}
+	for _, dir := range []string{"statement", "problem_statement"} {
+		for _, name := range []string{"problem.md", "problem.tex"} {
+			if statement, err := a.read(path.Join(dir, name)); err == nil {
+				return statement
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,206 @@
+package problempkg
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/khayrultw/go-judge/judge"
+	"github.com/khayrultw/go-judge/models"
+)
+
+// maxFileSize guards against packages that unpack into huge files
+const maxFileSize = 256 << 20
+
+type SourceFile struct {
+	Name     string
+	Language string
+	Source   string
+}
+
+// Package is a problem read from a Polygon or Kattis problem package,
+// before it is stored as a models.Problem
+type Package struct {
+	Title           string
+	Sections        models.StatementSections
+	Language        string                    // of Title and Sections
+	Statements      []models.ProblemStatement // translations
+	SampleTests     []uint                    // numbers of the tests shown as samples
+	TimeLimit       uint                      // milliseconds
+	MemoryLimit     uint                      // megabytes
+	Tests           []judge.TestCase
+	Checker         *SourceFile
+	CheckerProtocol string
+	Validator       *SourceFile
+	Solutions       []models.ReferenceSolution
+	Generators      []models.Generator
+	GeneratorScript string
+	Harnesses       []models.Harness
+	Type            string            // models.ProblemTypeStandard unless output-only
+	InputFile       string            // read instead of stdin, if set
+	OutputFile      string            // written instead of stdout, if set
+	Resources       map[string]string // headers like testlib.h, by file name
+	Tags            []string
+	Source          string
+	Difficulty      uint
+	Warnings        []string
+}
+
+func (p *Package) warn(format string, args ...interface{}) {
+	p.Warnings = append(p.Warnings, fmt.Sprintf(format, args...))
+}
+
+func ReadFile(name string) (*Package, error) {
+	r, err := zip.OpenReader(name)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return Read(&r.Reader)
+}
+
+// Read detects the format of the package from its descriptor, problem.xml
+// for Polygon and problem.yaml for Kattis
+func Read(r *zip.Reader) (*Package, error) {
+	return read(newArchive(r))
+}
+
+// ReadDir reads a package that is unpacked in dir
+func ReadDir(dir string) (*Package, error) {
+	a := archive{files: make(map[string]opener)}
+	err := filepath.WalkDir(dir, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, name)
+		if err != nil {
+			return err
+		}
+		a.files[filepath.ToSlash(rel)] = localFile(name)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return read(a)
+}
+
+func read(a archive) (*Package, error) {
+	switch {
+	case a.has("problem.xml"):
+		return readPolygon(a)
+	case a.has("problem.yaml"):
+		return readKattis(a)
+	}
+	return nil, fmt.Errorf("Unrecognized package: expected problem.xml (Polygon) or problem.yaml (Kattis)")
+}
+
+type archive struct {
+	files map[string]opener
+}
+
+// opener is a file of a package, in a zip like *zip.File or a directory
+type opener interface {
+	Open() (io.ReadCloser, error)
+}
+
+type localFile string
+
+func (f localFile) Open() (io.ReadCloser, error) {
+	return os.Open(string(f))
+}
+
+// newArchive indexes the files of the zip. A zip of the package folder
+// itself is read as if the folder was the root.
+func newArchive(r *zip.Reader) archive {
+	a := archive{files: make(map[string]opener)}
+	root := ""
+	for i, f := range r.File {
+		dir, _, found := strings.Cut(f.Name, "/")
+		if !found || (i > 0 && dir != root) {
+			root = ""
+			break
+		}
+		root = dir
+	}
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		name := f.Name
+		if root != "" {
+			name = strings.TrimPrefix(name, root+"/")
+		}
+		a.files[path.Clean(name)] = f
+	}
+	return a
+}
+
+func (a archive) has(name string) bool {
+	_, ok := a.files[name]
+	return ok
+}
+
+func (a archive) read(name string) (string, error) {
+	f, ok := a.files[name]
+	if !ok {
+		return "", fmt.Errorf("%s is missing from the package", name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+	content, err := io.ReadAll(io.LimitReader(rc, maxFileSize+1))
+	if err != nil {
+		return "", err
+	}
+	if len(content) > maxFileSize {
+		return "", fmt.Errorf("%s is too large", name)
+	}
+	return string(content), nil
+}
+
+// list returns the files under dir, sorted by name
+func (a archive) list(dir string) []string {
+	var names []string
+	for name := range a.files {
+		if strings.HasPrefix(name, dir+"/") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (a archive) readSource(name, lang string) (*SourceFile, error) {
+	source, err := a.read(name)
+	if err != nil {
+		return nil, err
+	}
+	return &SourceFile{Name: path.Base(name), Language: lang, Source: source}, nil
+}
+
+// languageForExtension maps a file extension to one of the languages
+// compile.sh supports, or "" if there is none
+func languageForExtension(name string) string {
+	switch path.Ext(name) {
+	case ".cpp", ".cc", ".cxx":
+		return "cpp"
+	case ".py":
+		return "py"
+	case ".kt":
+		return "kt"
+	case ".js":
+		return "js"
+	case ".java":
+		return "java"
+	}
+	return ""
+}
@@ -0,0 +1,262 @@
+package problempkg
+
+import (
+	"encoding/xml"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/khayrultw/go-judge/judge"
+	"github.com/khayrultw/go-judge/models"
+)
+
+type polygonSource struct {
+	Path string `xml:"path,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type polygonTestset struct {
+	Name          string `xml:"name,attr"`
+	TimeLimit     uint   `xml:"time-limit"`
+	MemoryLimit   uint64 `xml:"memory-limit"`
+	TestCount     int    `xml:"test-count"`
+	InputPattern  string `xml:"input-path-pattern"`
+	AnswerPattern string `xml:"answer-path-pattern"`
+	Tests         []struct {
+		Sample bool `xml:"sample,attr"`
+	} `xml:"tests>test"`
+}
+
+type polygonProblem struct {
+	Names []struct {
+		Language string `xml:"language,attr"`
+		Value    string `xml:"value,attr"`
+	} `xml:"names>name"`
+	Tags []struct {
+		Value string `xml:"value,attr"`
+	} `xml:"tags>tag"`
+	Judging struct {
+		InputFile  string `xml:"input-file,attr"`
+		OutputFile string `xml:"output-file,attr"`
+	} `xml:"judging"`
+	Testsets  []polygonTestset `xml:"judging>testset"`
+	Resources []struct {
+		Path string `xml:"path,attr"`
+	} `xml:"files>resources>file"`
+	Checker struct {
+		Source polygonSource `xml:"source"`
+	} `xml:"assets>checker"`
+	Validators []struct {
+		Source polygonSource `xml:"source"`
+	} `xml:"assets>validators>validator"`
+	Solutions []struct {
+		Tag    string        `xml:"tag,attr"`
+		Source polygonSource `xml:"source"`
+	} `xml:"assets>solutions>solution"`
+}
+
+// polygonVerdicts maps the solution tags of Polygon to expected verdicts.
+// Solutions with any other tag are not imported.
+var polygonVerdicts = map[string]string{
+	"main":                judge.VerdictAccepted,
+	"accepted":            judge.VerdictAccepted,
+	"wrong-answer":        judge.VerdictWrongAnswer,
+	"time-limit-exceeded": judge.VerdictTimeLimit,
+}
+
+// polygonLanguage maps a Polygon source type like "cpp.g++17" to one of the
+// languages compile.sh supports
+func polygonLanguage(sourceType string) string {
+	switch {
+	case strings.HasPrefix(sourceType, "cpp."):
+		return "cpp"
+	case strings.HasPrefix(sourceType, "python."):
+		return "py"
+	case strings.HasPrefix(sourceType, "kotlin"):
+		return "kt"
+	case strings.HasPrefix(sourceType, "js"):
+		return "js"
+	case strings.HasPrefix(sourceType, "java") && !strings.HasPrefix(sourceType, "javascript"):
+		return "java"
+	}
+	return ""
+}
+
+func readPolygon(a archive) (*Package, error) {
+	descriptor, err := a.read("problem.xml")
+	if err != nil {
+		return nil, err
+	}
+	var problem polygonProblem
+	if err := xml.Unmarshal([]byte(descriptor), &problem); err != nil {
+		return nil, fmt.Errorf("Invalid problem.xml: %v", err)
+	}
+	if len(problem.Testsets) == 0 {
+		return nil, fmt.Errorf("problem.xml has no testset")
+	}
+
+	pkg := &Package{Resources: make(map[string]string), CheckerProtocol: judge.CheckerTestlib, Type: models.ProblemTypeStandard}
+
+	language := ""
+	for _, name := range problem.Names {
+		if language == "" || name.Language == "english" {
+			language = name.Language
+			pkg.Title = name.Value
+		}
+	}
+	pkg.Language = polygonLanguageCodes[language]
+	if pkg.Language == "" {
+		pkg.Language = "en"
+	}
+	pkg.Sections = readPolygonStatement(a, language)
+
+	for _, name := range problem.Names {
+		if name.Language == language {
+			continue
+		}
+		code, ok := polygonLanguageCodes[name.Language]
+		if !ok {
+			pkg.warn("The %s statement was not imported", name.Language)
+			continue
+		}
+		pkg.Statements = append(pkg.Statements, models.ProblemStatement{
+			Language:          code,
+			Title:             name.Value,
+			StatementSections: readPolygonStatement(a, name.Language),
+		})
+	}
+	for _, tag := range problem.Tags {
+		pkg.Tags = append(pkg.Tags, tag.Value)
+	}
+
+	testset := problem.Testsets[0]
+	for _, t := range problem.Testsets {
+		if t.Name == "tests" {
+			testset = t
+		}
+	}
+	pkg.TimeLimit = testset.TimeLimit
+	pkg.InputFile = problem.Judging.InputFile
+	pkg.OutputFile = problem.Judging.OutputFile
+	pkg.MemoryLimit = uint(testset.MemoryLimit >> 20)
+
+	for i := 1; i <= testset.TestCount; i++ {
+		input, err := a.read(fmt.Sprintf(testset.InputPattern, i))
+		if err != nil {
+			return nil, err
+		}
+		answer, err := a.read(fmt.Sprintf(testset.AnswerPattern, i))
+		if err != nil {
+			return nil, fmt.Errorf("%v, download the full package to get the answers", err)
+		}
+		pkg.Tests = append(pkg.Tests, judge.TestCase{
+			Number: i,
+			Input:  strings.TrimSpace(input),
+			Output: strings.TrimSpace(answer),
+		})
+		if i <= len(testset.Tests) && testset.Tests[i-1].Sample {
+			pkg.SampleTests = append(pkg.SampleTests, uint(i))
+		}
+	}
+
+	for _, resource := range problem.Resources {
+		if path.Ext(resource.Path) != ".h" {
+			continue
+		}
+		content, err := a.read(resource.Path)
+		if err != nil {
+			return nil, err
+		}
+		pkg.Resources[path.Base(resource.Path)] = content
+	}
+
+	if source := problem.Checker.Source; source.Path != "" {
+		if lang := polygonLanguage(source.Type); lang != "" {
+			if pkg.Checker, err = a.readSource(source.Path, lang); err != nil {
+				return nil, err
+			}
+		} else {
+			pkg.warn("Checker %s of type %s is not supported, outputs will be compared exactly", source.Path, source.Type)
+		}
+	}
+
+	for _, validator := range problem.Validators {
+		lang := polygonLanguage(validator.Source.Type)
+		if lang == "" {
+			pkg.warn("Validator %s of type %s is not supported", validator.Source.Path, validator.Source.Type)
+			continue
+		}
+		if pkg.Validator, err = a.readSource(validator.Source.Path, lang); err != nil {
+			return nil, err
+		}
+		break
+	}
+
+	for _, solution := range problem.Solutions {
+		expected, ok := polygonVerdicts[solution.Tag]
+		lang := polygonLanguage(solution.Source.Type)
+		if !ok || lang == "" {
+			pkg.warn("Solution %s (%s, %s) was not imported", solution.Source.Path, solution.Tag, solution.Source.Type)
+			continue
+		}
+		source, err := a.read(solution.Source.Path)
+		if err != nil {
+			return nil, err
+		}
+		pkg.Solutions = append(pkg.Solutions, models.ReferenceSolution{
+			Language:   lang,
+			SourceCode: source,
+			Expected:   expected,
+		})
+	}
+
+	return pkg, nil
+}
+
+// polygonLanguageCodes maps the statement languages of Polygon to language
+// codes
+var polygonLanguageCodes = map[string]string{
+	"english":    "en",
+	"russian":    "ru",
+	"ukrainian":  "uk",
+	"bengali":    "bn",
+	"spanish":    "es",
+	"portuguese": "pt",
+	"french":     "fr",
+	"german":     "de",
+	"chinese":    "zh",
+	"japanese":   "ja",
+	"korean":     "ko",
+	"arabic":     "ar",
+	"persian":    "fa",
+	"vietnamese": "vi",
+	"indonesian": "id",
+}
+
+// readPolygonStatement reads the statement sections of the given language,
+// or else its whole statement as the legend
+func readPolygonStatement(a archive, language string) models.StatementSections {
+	dir := path.Join("statement-sections", language)
+	read := func(file string) string {
+		content, err := a.read(path.Join(dir, file))
+		if err != nil {
+			return ""
+		}
+		return strings.TrimSpace(content)
+	}
+	sections := models.StatementSections{
+		Legend:       read("legend.tex"),
+		InputFormat:  read("input.tex"),
+		OutputFormat: read("output.tex"),
+		Notes:        read("notes.tex"),
+	}
+	if sections != (models.StatementSections{}) {
+		return sections
+	}
+
+	statement, err := a.read(path.Join("statements", language, "problem.tex"))
+	if err != nil {
+		return sections
+	}
+	return models.StatementSections{Legend: statement}
+}
@@ -0,0 +1,163 @@
+package problempkg
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/khayrultw/go-judge/database"
+	"github.com/khayrultw/go-judge/judge"
+	"github.com/khayrultw/go-judge/models"
+	"github.com/khayrultw/go-judge/utils"
+	"gorm.io/gorm"
+)
+
+// Save creates the problem in the archive, writes the tests and programs of
+// the package under store/test_cases and, unless link is nil, adds the
+// problem to link.ContestId. authorId is 0 for imports from the command line.
+func Save(db *gorm.DB, pkg *Package, link *models.ContestProblem, authorId uint) (*models.Problem, error) {
+	for _, name := range []string{pkg.InputFile, pkg.OutputFile} {
+		if name != "" && (name != filepath.Base(name) || name == "..") {
+			return nil, fmt.Errorf("Invalid input or output file %s", name)
+		}
+	}
+
+	problem := models.Problem{
+		Title:             pkg.Title,
+		Statement:         pkg.Sections.Markdown(nil),
+		StatementSections: pkg.Sections,
+		SampleTests:       pkg.SampleTests,
+		TestVersion:       1,
+		TimeLimit:         pkg.TimeLimit,
+		MemoryLimit:       pkg.MemoryLimit,
+		Solutions:         pkg.Solutions,
+		Generators:        pkg.Generators,
+		GeneratorScript:   pkg.GeneratorScript,
+		Harnesses:         pkg.Harnesses,
+		Type:              pkg.Type,
+		InputFile:         pkg.InputFile,
+		OutputFile:        pkg.OutputFile,
+		Source:            pkg.Source,
+		Difficulty:        pkg.Difficulty,
+		DefaultLanguage:   pkg.Language,
+		Statements:        pkg.Statements,
+	}
+
+	var tagNames []string
+	for _, tag := range pkg.Tags {
+		if tag = strings.ToLower(strings.TrimSpace(tag)); tag != "" && !slices.Contains(tagNames, tag) {
+			tagNames = append(tagNames, tag)
+		}
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if link != nil {
+			if err := tx.First(&models.Contest{}, link.ContestId).Error; err != nil {
+				return fmt.Errorf("Contest not found")
+			}
+		}
+		tags, err := database.FindOrCreateTags(tx, tagNames)
+		if err != nil {
+			return fmt.Errorf("Failed to save tags")
+		}
+		problem.Tags = tags
+		if err := tx.Create(&problem).Error; err != nil {
+			return fmt.Errorf("Failed to create problem")
+		}
+		if err := writeFiles(pkg, &problem); err != nil {
+			return err
+		}
+		if err := validateTests(pkg, problem); err != nil {
+			return err
+		}
+		if err := database.SaveRevision(tx, &problem, authorId, "Imported"); err != nil {
+			return fmt.Errorf("Failed to create problem")
+		}
+
+		if link == nil {
+			return nil
+		}
+		link.ProblemId = problem.Id
+		if link.Label == "" {
+			link.Label = models.DefaultLabel(link.Position)
+		}
+		if err := tx.Create(link).Error; err != nil {
+			return fmt.Errorf("Failed to add problem to contest")
+		}
+		problem.ContestId, problem.ProblemNumber, problem.Label = link.ContestId, link.Position, link.Label
+		return nil
+	})
+	if err != nil {
+		if problem.Id != 0 {
+			os.RemoveAll(utils.ProblemDir(problem.Id))
+		}
+		return nil, err
+	}
+	return &problem, nil
+}
+
+// writeFiles stores the tests, checker, validator and headers of the
+// package next to each other and records their paths in problem
+func writeFiles(pkg *Package, problem *models.Problem) error {
+	problem.TestCasePath = utils.ProblemFilePath(problem.Id, utils.TestCaseFileName(1))
+	if err := utils.WriteProblemFile(problem.TestCasePath, judge.FormatTestCases(pkg.Tests)); err != nil {
+		return err
+	}
+
+	// headers are included by their name, so they go next to the programs
+	for name, content := range pkg.Resources {
+		if err := utils.WriteProblemFile(utils.ProblemFilePath(problem.Id, filepath.Base(name)), content); err != nil {
+			return err
+		}
+	}
+
+	if pkg.Checker != nil {
+		problem.CheckerPath = utils.ProblemFilePath(problem.Id, "checker."+pkg.Checker.Language)
+		problem.CheckerLanguage = pkg.Checker.Language
+		problem.CheckerProtocol = pkg.CheckerProtocol
+		if err := utils.WriteProblemFile(problem.CheckerPath, pkg.Checker.Source); err != nil {
+			return err
+		}
+	}
+
+	if pkg.Validator != nil {
+		problem.ValidatorPath = utils.ProblemFilePath(problem.Id, "validator."+pkg.Validator.Language)
+		problem.ValidatorLanguage = pkg.Validator.Language
+		if err := utils.WriteProblemFile(problem.ValidatorPath, pkg.Validator.Source); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// InvalidTestsError is returned by Save when the validator of the package
+// rejects some of its tests
+type InvalidTestsError struct {
+	Reports []judge.ValidationReport
+}
+
+func (e *InvalidTestsError) Error() string {
+	tests := make([]string, len(e.Reports))
+	for i, report := range e.Reports {
+		tests[i] = fmt.Sprintf("test %d: %s", report.Test, report.Message)
+	}
+	return "Test validation failed: " + strings.Join(tests, "; ")
+}
+
+// validateTests runs the validator of the package over its tests, once the
+// headers it includes are written next to it
+func validateTests(pkg *Package, problem models.Problem) error {
+	if pkg.Validator == nil {
+		return nil
+	}
+	reports, err := judge.ValidateTests(pkg.Validator.Source, pkg.Validator.Language, judge.FormatTestCases(pkg.Tests), utils.ProblemDir(problem.Id))
+	if err != nil {
+		return err
+	}
+	if len(reports) > 0 {
+		return &InvalidTestsError{Reports: reports}
+	}
+	return nil
+}
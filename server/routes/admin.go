@@ -0,0 +1,11 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/khayrultw/go-judge/controllers"
+)
+
+func RegisterAdminRoutes(rg *gin.RouterGroup) {
+	rg.GET("/judge/health", controllers.JudgeHealth)
+	rg.POST("/judge/health", controllers.RunSelfTest)
+}
@@ -15,9 +15,14 @@ func RegisterContestRoutes(rg *gin.RouterGroup) {
 	rg.GET("/:contestId/submissions", middleware.RequireAuth, contestController.GetAllSubmissions)
 	rg.GET("/:contestId/submissions/my", middleware.RequireAuth, contestController.GetMySubmissions)
 	rg.GET("/:contestId", middleware.RequireAuth, contestController.GetContest)
+	rg.GET("/:contestId/problems", middleware.RequireAuth, contestController.GetContestProblems)
+	rg.POST("/:contestId/problems", middleware.RequireAuth, middleware.RequireAdmin, contestController.AddProblemToContest)
+	rg.PUT("/:contestId/problems/:problemId", middleware.RequireAuth, middleware.RequireAdmin, contestController.UpdateProblemInContest)
+	rg.DELETE("/:contestId/problems/:problemId", middleware.RequireAuth, middleware.RequireAdmin, contestController.DeleteProblemFromContest)
 	rg.PUT("/:contestId", middleware.RequireAuth, middleware.RequireAdmin, contestController.UpdateContest)
 	rg.GET("", middleware.RequireAuth, contestController.GetContests)
 	rg.GET("/upcomming", middleware.RequireAuth, contestController.GetUpcomingContests)
 	rg.DELETE("/:contestId", middleware.RequireAuth, middleware.RequireAdmin, contestController.DeleteContest)
+	rg.POST("/:contestId/rejudge", middleware.RequireAuth, middleware.RequireAdmin, contestController.RejudgeContest)
 	rg.GET("/:contestId/standings", middleware.RequireAuth, contestController.GetStandings)
 }
@@ -9,7 +9,36 @@ import (
 func RegisterProblemRoutes(rg *gin.RouterGroup) {
 	problemController := controllers.NewProblemController()
 	rg.POST("", middleware.RequireAdmin, problemController.CreateProblem)
+	rg.POST("/import", middleware.RequireAdmin, problemController.ImportProblem)
 	rg.GET("/:problemId", middleware.RequireStarted, problemController.GetProblem)
 	rg.PUT("/:problemId", middleware.RequireAdmin, problemController.UpdateProblem)
+	rg.POST("/:problemId/verify", middleware.RequireAdmin, problemController.VerifyProblem)
+	rg.GET("/:problemId/verify", middleware.RequireAdmin, problemController.GetVerification)
+	rg.POST("/:problemId/generate", middleware.RequireAdmin, problemController.GenerateTests)
+	rg.POST("/:problemId/rejudge", middleware.RequireAdmin, problemController.RejudgeProblem)
+	rg.GET("/:problemId/export", middleware.RequireAdmin, problemController.ExportProblem)
+	rg.GET("/:problemId/revisions", middleware.RequireAdmin, problemController.ListRevisions)
+	rg.GET("/:problemId/revisions/:revision", middleware.RequireAdmin, problemController.GetRevision)
+	rg.POST("/:problemId/revisions/:revision/rollback", middleware.RequireAdmin, problemController.RollbackProblem)
+	rg.GET("/:problemId/diff", middleware.RequireAdmin, problemController.DiffRevisions)
 	rg.DELETE("/:problemId", middleware.RequireAdmin, problemController.DeleteProblem)
+	rg.GET("/:problemId/statements", middleware.RequireStarted, problemController.ListStatements)
+	rg.PUT("/:problemId/statements/:language", middleware.RequireAdmin, problemController.SaveStatement)
+	rg.DELETE("/:problemId/statements/:language", middleware.RequireAdmin, problemController.DeleteStatement)
+	rg.GET("/:problemId/attachments", middleware.RequireStarted, problemController.ListAttachments)
+	rg.POST("/:problemId/attachments", middleware.RequireAdmin, problemController.UploadAttachment)
+	rg.DELETE("/:problemId/attachments/:name", middleware.RequireAdmin, problemController.DeleteAttachment)
+}
+
+func RegisterArchiveRoutes(rg *gin.RouterGroup) {
+	problemController := controllers.NewProblemController()
+	rg.GET("", problemController.ListProblems)
+	rg.GET("/tags", problemController.ListTags)
+}
+
+// RegisterAttachmentRoutes serves attachments without the Authorization
+// header, so that statements can embed them
+func RegisterAttachmentRoutes(rg *gin.RouterGroup) {
+	problemController := controllers.NewProblemController()
+	rg.GET("/:problemId/:name", middleware.AllowTokenInQuery, middleware.RequireStarted, problemController.ServeAttachment)
 }
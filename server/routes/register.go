@@ -8,8 +8,8 @@ import (
 func RegisterAllRoutes(r *gin.RouterGroup) {
 	RegisterAuthRoutes(r)
 
-	testGroup := r.Group("/test")
-	RegisterTestRoutes(testGroup)
+	adminGroup := r.Group("/admin", middleware.RequireAuth, middleware.RequireAdmin)
+	RegisterAdminRoutes(adminGroup)
 
 	contestGroup := r.Group("/contests")
 	RegisterContestRoutes(contestGroup)
@@ -17,6 +17,18 @@ func RegisterAllRoutes(r *gin.RouterGroup) {
 	problemGroup := r.Group("/problem", middleware.RequireAuth)
 	RegisterProblemRoutes(problemGroup)
 
+	archiveGroup := r.Group("/problems", middleware.RequireAuth)
+	RegisterArchiveRoutes(archiveGroup)
+
+	attachmentGroup := r.Group("/attachments")
+	RegisterAttachmentRoutes(attachmentGroup)
+
 	submissionGroup := r.Group("/submissions")
 	RegisterSubmissionRoutes(submissionGroup)
+
+	runGroup := r.Group("/run")
+	RegisterRunRoutes(runGroup)
+
+	workerGroup := r.Group("/judge")
+	RegisterWorkerRoutes(workerGroup)
 }
@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/khayrultw/go-judge/controllers"
+	"github.com/khayrultw/go-judge/middleware"
+)
+
+func RegisterRunRoutes(rg *gin.RouterGroup) {
+	runController := controllers.NewRunController()
+	rg.POST("", middleware.RequireAuth, middleware.RateLimit(10, time.Minute), runController.Run)
+}
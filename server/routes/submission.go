@@ -9,7 +9,16 @@ import (
 func RegisterSubmissionRoutes(rg *gin.RouterGroup) {
 	submissionController := controllers.NewSubmissionController()
 	rg.POST("/:problemId", middleware.RequireAuth, middleware.RequireStarted, submissionController.SubmitCode)
+	rg.POST("/:problemId/outputs", middleware.RequireAuth, middleware.RequireStarted, submissionController.SubmitOutputs)
+	rg.POST("/:problemId/archive", middleware.RequireAuth, middleware.RequireStarted, submissionController.SubmitArchive)
+	rg.POST("/rejudge/:submissionId", middleware.RequireAuth, middleware.RequireAdmin, submissionController.RejudgeSubmission)
+	rg.POST("/cancel/:submissionId", middleware.RequireAuth, submissionController.CancelSubmission)
+	rg.POST("/override/:submissionId", middleware.RequireAuth, middleware.RequireAdmin, submissionController.OverrideVerdict)
+	rg.DELETE("/override/:submissionId", middleware.RequireAuth, middleware.RequireAdmin, submissionController.ClearOverride)
+	rg.GET("/overrides", middleware.RequireAuth, middleware.RequireAdmin, submissionController.ListOverrides)
 	rg.GET("/:submissionId", middleware.RequireAuth, submissionController.GetSubmission)
+	rg.GET("/:submissionId/sse", middleware.RequireTokenInQuery, submissionController.SSESubmission)
+	rg.GET("/:submissionId/history", middleware.RequireAuth, middleware.RequireAdmin, submissionController.GetSubmissionHistory)
 	rg.GET("/my", middleware.RequireAuth, submissionController.GetMySubmissions)
 	rg.GET("/sse/my", middleware.RequireTokenInQuery, submissionController.SSEMySubmissions)
 }
@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/khayrultw/go-judge/controllers"
+	"github.com/khayrultw/go-judge/middleware"
+)
+
+func RegisterWorkerRoutes(rg *gin.RouterGroup) {
+	workerController := controllers.NewWorkerController()
+	rg.GET("/workers", middleware.RequireAuth, middleware.RequireAdmin, workerController.ListWorkers)
+	rg.POST("/workers", middleware.RequireWorker, workerController.Register)
+	rg.POST("/lease", middleware.RequireWorker, workerController.Lease)
+	rg.GET("/files/:hash", middleware.RequireWorker, workerController.File)
+	rg.POST("/heartbeat", middleware.RequireWorker, workerController.Heartbeat)
+	rg.POST("/progress", middleware.RequireWorker, workerController.Progress)
+	rg.POST("/result", middleware.RequireWorker, workerController.Result)
+}
@@ -14,13 +14,32 @@ func newBroadcaster() *Broadcaster {
 }
 
 func (b *Broadcaster) Subscribe(topic string) SSEClient {
-	ch := make(SSEClient)
+	// buffered so that a client busy writing an event misses fewer
+	// of the next ones
+	ch := make(SSEClient, 16)
 	b.mu.Lock()
 	b.clients[topic] = append(b.clients[topic], ch)
 	b.mu.Unlock()
 	return ch
 }
 
+// Unsubscribe removes the client from the topic and closes it
+func (b *Broadcaster) Unsubscribe(topic string, ch SSEClient) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	clients := b.clients[topic]
+	for i, client := range clients {
+		if client == ch {
+			b.clients[topic] = append(clients[:i], clients[i+1:]...)
+			close(ch)
+			break
+		}
+	}
+	if len(b.clients[topic]) == 0 {
+		delete(b.clients, topic)
+	}
+}
+
 func (b *Broadcaster) Publish(topic string, msg string) {
 	b.mu.RLock()
 	defer b.mu.RUnlock()
@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+)
+
+// ProblemFilePath returns where a file of a problem, such as its tests or
+// validator, is kept under store/test_cases. Problems created while they
+// still belonged to a contest keep their files under contest_<id>.
+func ProblemFilePath(problemId uint, name string) string {
+	return filepath.Join(ProblemDir(problemId), name)
+}
+
+// ProblemDir is the directory ProblemFilePath keeps the files of a problem
+// in
+func ProblemDir(problemId uint) string {
+	return filepath.Join("store/test_cases", fmt.Sprintf("problem_%d", problemId))
+}
+
+// AttachmentDir is where the attachments of a problem are kept, apart from
+// its tests. Like everything under store they are not served as they are,
+// only by ServeAttachment once the problem can be opened.
+func AttachmentDir(problemId uint) string {
+	return filepath.Join("store/attachments", fmt.Sprintf("problem_%d", problemId))
+}
+
+// AttachmentURL is the stable URL statements use to refer to an attachment
+func AttachmentURL(problemId uint, name string) string {
+	return fmt.Sprintf("/api/attachments/%d/%s", problemId, url.PathEscape(name))
+}
+
+// SubmissionOutputPath is where the output a submission to an output-only
+// problem gave for a test is kept. Only the judge reads it.
+func SubmissionOutputPath(submissionId uint, test int) string {
+	return filepath.Join(SubmissionOutputDir(submissionId), fmt.Sprintf("%03d.out", test))
+}
+
+// SubmissionOutputDir holds the outputs of a submission
+func SubmissionOutputDir(submissionId uint) string {
+	return filepath.Join("store/submissions", fmt.Sprintf("submission_%d", submissionId))
+}
+
+// TestCaseFileName names each version of the tests separately so that
+// replacing the tests never overwrites an older set
+func TestCaseFileName(version uint) string {
+	return fmt.Sprintf("testcase_v%d.txt", version)
+}
+
+func WriteProblemFile(path, content string) error {
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return fmt.Errorf("Failed to create directory")
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return fmt.Errorf("Failed to write %s", filepath.Base(path))
+	}
+	return nil
+}